@@ -0,0 +1,331 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/stackb/protoreflecthash"
+)
+
+// The formats messages are read in.
+const (
+	formatAuto   = "auto"
+	formatBinary = "binary"
+	formatJSON   = "json"
+	formatText   = "text"
+)
+
+// textExtensions are the file extensions of messages in text format.
+var textExtensions = map[string]bool{
+	".textproto": true,
+	".txtpb":     true,
+	".pbtxt":     true,
+	".prototxt":  true,
+}
+
+// binaryExtensions are the file extensions of messages in binary format.  As
+// any file can hold a binary message, they are only used to find the message
+// files of a directory.
+var binaryExtensions = map[string]bool{
+	".pb":    true,
+	".binpb": true,
+}
+
+var unknownFieldsModes = map[string]protoreflecthash.UnknownFieldsMode{
+	"ignore":  protoreflecthash.UnknownFieldsIgnore,
+	"reject":  protoreflecthash.UnknownFieldsReject,
+	"include": protoreflecthash.UnknownFieldsInclude,
+}
+
+var unresolvedAnyPolicies = map[string]protoreflecthash.UnresolvedAnyPolicy{
+	"fail":   protoreflecthash.UnresolvedAnyFail,
+	"opaque": protoreflecthash.UnresolvedAnyOpaque,
+	"wire":   protoreflecthash.UnresolvedAnyWire,
+}
+
+var validationModes = map[string]protoreflecthash.ValidationMode{
+	"required": protoreflecthash.ValidateRequired,
+	"none":     protoreflecthash.ValidateNone,
+	"strict":   protoreflecthash.ValidateStrict,
+}
+
+var hashFuncs = map[string]func() protoreflecthash.Option{
+	"sha256":     protoreflecthash.SHA256,
+	"sha384":     protoreflecthash.SHA384,
+	"sha512_256": protoreflecthash.SHA512_256,
+	"sha3_256":   protoreflecthash.SHA3_256,
+	"blake3":     protoreflecthash.BLAKE3,
+}
+
+var keyScopes = map[string]protoreflecthash.KeyScope{
+	"root": protoreflecthash.KeyRoot,
+	"all":  protoreflecthash.KeyAllNodes,
+}
+
+// config holds the flags shared by the commands: where the message types are
+// described, how messages are read and how they are hashed.
+type config struct {
+	protoset string
+	typeName string
+	format   string
+
+	fullnameIdentifier bool
+	fieldNames         bool
+	unknownFields      string
+	unresolvedAny      string
+	rejectExtensions   bool
+	validation         string
+	hashFunc           string
+	hmacKeyFile        string
+	hmacKeyID          string
+	hmacScope          string
+
+	// files holds the descriptors loaded from the protoset.
+	files *protoregistry.Files
+	// resolver resolves the message types and extensions of files.
+	resolver resolver
+}
+
+// resolver resolves message types and extensions, as required to read
+// messages in every format.
+type resolver interface {
+	protoregistry.MessageTypeResolver
+	protoregistry.ExtensionTypeResolver
+}
+
+// register defines the flags shared by all commands in fs: the protoset and
+// the options of the hasher.
+func (c *config) register(fs *flag.FlagSet) {
+	fs.StringVar(&c.protoset, "protoset", "", "`file` holding a FileDescriptorSet describing the message types (required)")
+	fs.BoolVar(&c.fullnameIdentifier, "fullname_identifier", false, "identify messages by the full name of their type")
+	fs.BoolVar(&c.fieldNames, "field_names", false, "key fields by name rather than number")
+	fs.StringVar(&c.unknownFields, "unknown_fields", "ignore", "how to hash unknown fields: `mode` ignore, reject or include")
+	fs.StringVar(&c.unresolvedAny, "unresolved_any", "fail", "how to hash google.protobuf.Any messages of unknown types: `policy` fail, opaque or wire")
+	fs.BoolVar(&c.rejectExtensions, "reject_extensions", false, "fail on messages having extension fields")
+	fs.StringVar(&c.validation, "validation", "required", "how to validate messages: `mode` required, none or strict")
+	fs.StringVar(&c.hashFunc, "hash", "sha256", "hash `function`: sha256, sha384, sha512_256, sha3_256 or blake3")
+	fs.StringVar(&c.hmacKeyFile, "hmac_key_file", "", "`file` holding a key to compute hashes with HMAC")
+	fs.StringVar(&c.hmacKeyID, "hmac_key_id", "", "identifier of the HMAC key")
+	fs.StringVar(&c.hmacScope, "hmac_scope", "root", "nodes to hash with HMAC: `scope` root or all")
+}
+
+// registerType defines the -type flag in fs, with the given usage.
+func (c *config) registerType(fs *flag.FlagSet, usage string) {
+	fs.StringVar(&c.typeName, "type", "", usage)
+}
+
+// registerFormat defines the -format flag in fs.
+func (c *config) registerFormat(fs *flag.FlagSet) {
+	fs.StringVar(&c.format, "format", formatAuto, "`format` of the messages: binary, json, text or auto, which infers it from the file extension (.json, .textproto, .txtpb, .pbtxt or .prototxt, and binary otherwise)")
+}
+
+// load loads the descriptors of the protoset.
+func (c *config) load() error {
+	if c.protoset == "" {
+		return errors.New("-protoset is required")
+	}
+	data, err := ioutil.ReadFile(c.protoset)
+	if err != nil {
+		return err
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return fmt.Errorf("parsing %s: %w", c.protoset, err)
+	}
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return fmt.Errorf("loading %s (was it written with --include_imports?): %w", c.protoset, err)
+	}
+	c.files = files
+	c.resolver = protoreflecthash.FilesTypeResolver(files).(resolver)
+	return nil
+}
+
+// messageType returns the descriptor of the named message type.
+func (c *config) messageType(name string) (protoreflect.MessageDescriptor, error) {
+	if name == "" {
+		return nil, errors.New("-type is required")
+	}
+	d, err := c.files.FindDescriptorByName(protoreflect.FullName(name))
+	if errors.Is(err, protoregistry.NotFound) {
+		return nil, fmt.Errorf("unknown message type %s", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("message type %s: %w", name, err)
+	}
+	md, ok := d.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a message type", name)
+	}
+	return md, nil
+}
+
+// options returns the hasher options selected by the flags.
+func (c *config) options() ([]protoreflecthash.Option, error) {
+	options := []protoreflecthash.Option{
+		protoreflecthash.TypeResolver(c.resolver),
+	}
+	if c.fullnameIdentifier {
+		options = append(options, protoreflecthash.MessageFullnameIdentifier())
+	}
+	if c.fieldNames {
+		options = append(options, protoreflecthash.FieldNamesAsKeys())
+	}
+	if c.rejectExtensions {
+		options = append(options, protoreflecthash.RejectExtensions())
+	}
+
+	unknownFields, ok := unknownFieldsModes[c.unknownFields]
+	if !ok {
+		return nil, invalidFlag("unknown_fields", c.unknownFields, "ignore, reject or include")
+	}
+	unresolvedAny, ok := unresolvedAnyPolicies[c.unresolvedAny]
+	if !ok {
+		return nil, invalidFlag("unresolved_any", c.unresolvedAny, "fail, opaque or wire")
+	}
+	validation, ok := validationModes[c.validation]
+	if !ok {
+		return nil, invalidFlag("validation", c.validation, "required, none or strict")
+	}
+	hashFunc, ok := hashFuncs[c.hashFunc]
+	if !ok {
+		return nil, invalidFlag("hash", c.hashFunc, "sha256, sha384, sha512_256, sha3_256 or blake3")
+	}
+	options = append(options,
+		protoreflecthash.UnknownFields(unknownFields),
+		protoreflecthash.UnresolvedAny(unresolvedAny),
+		protoreflecthash.Validation(validation),
+		hashFunc(),
+	)
+
+	if c.hmacKeyFile != "" {
+		scope, ok := keyScopes[c.hmacScope]
+		if !ok {
+			return nil, invalidFlag("hmac_scope", c.hmacScope, "root or all")
+		}
+		key, err := ioutil.ReadFile(c.hmacKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, protoreflecthash.HMAC(c.hmacKeyID, key, scope))
+	}
+
+	return options, nil
+}
+
+// invalidFlag returns the error for an invalid value of a flag.
+func invalidFlag(name, value, want string) error {
+	return fmt.Errorf("invalid -%s %q (want %s)", name, value, want)
+}
+
+// hasher loads the protoset and returns the hasher selected by the flags.
+func (c *config) hasher() (protoreflecthash.ProtoHasher, error) {
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	options, err := c.options()
+	if err != nil {
+		return nil, err
+	}
+	return protoreflecthash.NewHasher(options...), nil
+}
+
+// setup loads the protoset and returns the hasher and the message type
+// selected by the flags.
+func (c *config) setup() (protoreflecthash.ProtoHasher, protoreflect.MessageDescriptor, error) {
+	h, err := c.hasher()
+	if err != nil {
+		return nil, nil, err
+	}
+	md, err := c.messageType(c.typeName)
+	if err != nil {
+		return nil, nil, err
+	}
+	return h, md, nil
+}
+
+// formatOf returns the format of the messages read from path, "-" being the
+// standard input.
+func (c *config) formatOf(path string) (string, error) {
+	switch c.format {
+	case formatBinary, formatJSON, formatText:
+		return c.format, nil
+	case formatAuto:
+	default:
+		return "", invalidFlag("format", c.format, "auto, binary, json or text")
+	}
+
+	ext := strings.ToLower(filepath.Ext(path))
+	switch {
+	case path == "-":
+		return formatBinary, nil
+	case ext == ".json":
+		return formatJSON, nil
+	case textExtensions[ext]:
+		return formatText, nil
+	}
+	return formatBinary, nil
+}
+
+// readFile reads the file at path, "-" being the standard input.
+func readFile(path string, stdin io.Reader) ([]byte, error) {
+	if path == "-" {
+		return ioutil.ReadAll(stdin)
+	}
+	return ioutil.ReadFile(path)
+}
+
+// decode decodes a message of the given type from data, read from path.
+func (c *config) decode(md protoreflect.MessageDescriptor, path string, data []byte) (protoreflect.Message, error) {
+	format, err := c.formatOf(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Required fields are left to the validation of the hasher, so that every
+	// format is validated alike.
+	msg := dynamicpb.NewMessage(md)
+	switch format {
+	case formatJSON:
+		err = protojson.UnmarshalOptions{Resolver: c.resolver, AllowPartial: true}.Unmarshal(data, msg)
+	case formatText:
+		err = prototext.UnmarshalOptions{Resolver: c.resolver, AllowPartial: true}.Unmarshal(data, msg)
+	default:
+		err = proto.UnmarshalOptions{Resolver: c.resolver, AllowPartial: true}.Unmarshal(data, msg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s message: %w", format, err)
+	}
+	return msg, nil
+}
+
+// hash returns the hash of the message of the given type read from path.
+// Binary messages are hashed without being decoded.
+func (c *config) hash(h protoreflecthash.ProtoHasher, md protoreflect.MessageDescriptor, path string, data []byte) ([]byte, error) {
+	format, err := c.formatOf(path)
+	if err != nil {
+		return nil, err
+	}
+	if format == formatBinary {
+		return h.(protoreflecthash.WireProtoHasher).HashWire(md, data)
+	}
+	msg, err := c.decode(md, path, data)
+	if err != nil {
+		return nil, err
+	}
+	return h.HashProto(msg)
+}
@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+func TestFormatOf(t *testing.T) {
+	for _, tc := range []struct {
+		format, path, want string
+	}{
+		{formatAuto, "-", formatBinary},
+		{formatAuto, "msg.pb", formatBinary},
+		{formatAuto, "msg", formatBinary},
+		{formatAuto, "msg.json", formatJSON},
+		{formatAuto, "MSG.JSON", formatJSON},
+		{formatAuto, "msg.textproto", formatText},
+		{formatAuto, "msg.txtpb", formatText},
+		{formatAuto, "msg.pbtxt", formatText},
+		{formatAuto, "msg.prototxt", formatText},
+		{formatText, "msg.json", formatText},
+		{formatJSON, "-", formatJSON},
+	} {
+		c := config{format: tc.format}
+		got, err := c.formatOf(tc.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tc.want {
+			t.Errorf("format %s of %s: want %s, got %s", tc.format, tc.path, tc.want, got)
+		}
+	}
+
+	c := config{format: "yaml"}
+	if _, err := c.formatOf("msg.yaml"); err == nil || err.Error() != `invalid -format "yaml" (want auto, binary, json or text)` {
+		t.Errorf("want invalid format error, got %v", err)
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+
+	"github.com/stackb/protoreflecthash"
+)
+
+// rootPath is how the path of the root message is displayed.
+const rootPath = "<root>"
+
+// absent is how a value present in only one of the messages is displayed on
+// the other side.
+const absent = "<absent>"
+
+// runDiff compares two messages of the same type by hash and prints each path
+// at which they differ, followed by the hash and the value at that path in
+// each message:
+//
+//	--- <first file>
+//	+++ <second file>
+//	<path>
+//	- <hex hash>  <value>
+//	+ <hex hash>  <value>
+//
+// The exit status is 0 if the messages have the same hash, 1 if they differ
+// and 2 on failure.
+func runDiff(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var c config
+	c.register(fs)
+	c.registerFormat(fs)
+	c.registerType(fs, "fully-qualified `name` of the message type (required)")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: protohash diff -protoset FILE -type NAME [flags] FILE1 FILE2\n\n")
+		fmt.Fprintf(stderr, "Prints the paths at which two messages differ by hash.  Either file may be\nthe standard input (\"-\").  The exit status is 1 if the messages differ.\n\nflags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 2
+	}
+
+	h, md, err := c.setup()
+	if err != nil {
+		fmt.Fprintf(stderr, "protohash: %v\n", err)
+		return 2
+	}
+
+	var msgs [2]protoreflect.Message
+	var trees [2]*protoreflecthash.HashNode
+	for i, path := range fs.Args() {
+		data, err := readFile(path, stdin)
+		if err == nil {
+			msgs[i], err = c.decode(md, path, data)
+		}
+		if err == nil {
+			trees[i], err = h.(protoreflecthash.TreeProtoHasher).HashTree(msgs[i])
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "protohash: %s: %v\n", path, err)
+			return 2
+		}
+	}
+
+	diff := protoreflecthash.DiffTrees(trees[0], trees[1])
+	if len(diff.Paths) == 0 {
+		return 0
+	}
+
+	fmt.Fprintf(stdout, "--- %s\n+++ %s\n", fs.Arg(0), fs.Arg(1))
+	for _, path := range diff.Paths {
+		if path == "" {
+			fmt.Fprintln(stdout, rootPath)
+		} else {
+			fmt.Fprintln(stdout, path)
+		}
+		for i, sign := range []string{"-", "+"} {
+			node := findNode(trees[i], path)
+			if node == nil {
+				fmt.Fprintf(stdout, "%s %s\n", sign, absent)
+				continue
+			}
+			fmt.Fprintf(stdout, "%s %s  %s\n", sign, node.Hash, c.render(msgs[i], path))
+		}
+	}
+	return 1
+}
+
+// findNode returns the node of the tree having the given path, or nil if the
+// value at that path is absent.
+func findNode(tree *protoreflecthash.HashNode, path string) *protoreflecthash.HashNode {
+	if path == "" || tree.Path == path {
+		return tree
+	}
+	for _, child := range tree.Children {
+		// Only the paths of ancestors are prefixes of the path.  Nodes
+		// without a path, within well-known types, are not descended into.
+		if child.Path != "" && strings.HasPrefix(path, child.Path) {
+			if node := findNode(child, path); node != nil {
+				return node
+			}
+		}
+	}
+	return nil
+}
+
+// render returns the value at the given path within msg, as displayed by
+// diff: messages in compact protojson form, strings and bytes quoted, enums by
+// name and lists and maps as their elements or entries, in brackets.
+func (c *config) render(msg protoreflect.Message, path string) string {
+	if path == "" {
+		return c.renderMessage(msg)
+	}
+	p, err := protoreflecthash.ParsePath(path)
+	if err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+	value, fd, err := p.Lookup(msg)
+	if err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+
+	element := p[len(p)-1].Key != nil
+	switch {
+	case fd.IsList() && !element:
+		list := value.List()
+		elements := make([]string, list.Len())
+		for i := range elements {
+			elements[i] = c.renderValue(fd, list.Get(i))
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case fd.IsMap() && !element:
+		return c.renderMap(fd, value.Map())
+	case fd.IsMap():
+		return c.renderValue(fd.MapValue(), value)
+	}
+	return c.renderValue(fd, value)
+}
+
+// renderMap renders the entries of a map ordered by key.
+func (c *config) renderMap(fd protoreflect.FieldDescriptor, m protoreflect.Map) string {
+	keys := make([]protoreflect.MapKey, 0, m.Len())
+	m.Range(func(mk protoreflect.MapKey, _ protoreflect.Value) bool {
+		keys = append(keys, mk)
+		return true
+	})
+	sort.Slice(keys, func(i, j int) bool {
+		return lessMapKey(keys[i], keys[j])
+	})
+
+	entries := make([]string, len(keys))
+	for i, mk := range keys {
+		entries[i] = c.renderValue(fd.MapKey(), mk.Value()) + ": " + c.renderValue(fd.MapValue(), m.Get(mk))
+	}
+	return "{" + strings.Join(entries, ", ") + "}"
+}
+
+// lessMapKey orders map keys of the same kind.
+func lessMapKey(a, b protoreflect.MapKey) bool {
+	switch a.Interface().(type) {
+	case bool:
+		return !a.Bool() && b.Bool()
+	case int32, int64:
+		return a.Int() < b.Int()
+	case uint32, uint64:
+		return a.Uint() < b.Uint()
+	}
+	return a.String() < b.String()
+}
+
+// renderValue renders a singular value of the given field.
+func (c *config) renderValue(fd protoreflect.FieldDescriptor, value protoreflect.Value) string {
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return c.renderMessage(value.Message())
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(value.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+		return strconv.Itoa(int(value.Enum()))
+	case protoreflect.StringKind:
+		return strconv.Quote(value.String())
+	case protoreflect.BytesKind:
+		return fmt.Sprintf("%q", value.Bytes())
+	}
+	return value.String()
+}
+
+// renderMessage renders a message in compact protojson form.
+func (c *config) renderMessage(msg protoreflect.Message) string {
+	data, err := protojson.MarshalOptions{Resolver: c.resolver}.Marshal(msg.Interface())
+	if err != nil {
+		return fmt.Sprintf("<%v>", err)
+	}
+	// protojson varies its whitespace; compacting makes the output stable.
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, data); err != nil {
+		return string(data)
+	}
+	return compact.String()
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/stackb/protoreflecthash"
+	pb3_latest "github.com/stackb/protoreflecthash/test_protos/generated/latest/proto3"
+)
+
+func TestDiff(t *testing.T) {
+	person := &pb3_latest.PersonV4{
+		Id:             1,
+		StructuredName: &pb3_latest.PersonV4_NameV4{First: "Ada", Last: "Lovelace"},
+		Children:       []*pb3_latest.PersonV3{{Id: 2}},
+	}
+	changed := proto.Clone(person).(*pb3_latest.PersonV4)
+	changed.StructuredName.First = "Augusta"
+	changed.Children = append(changed.Children, &pb3_latest.PersonV3{Id: 3})
+	changed.Profession = "mathematician"
+
+	for name, tc := range map[string]struct {
+		a, b proto.Message
+		// want holds, for each path at which the messages differ, the value
+		// at that path in a and in b, absent values being "".
+		want [][3]string
+	}{
+		"same": {
+			a: person,
+			b: person,
+		},
+		"fields": {
+			a: person,
+			b: changed,
+			want: [][3]string{
+				{"children[1]", "", `{"id":3}`},
+				{"structured_name.first", `"Ada"`, `"Augusta"`},
+				{"profession", "", `"mathematician"`},
+			},
+		},
+		"map": {
+			a: &pb3_latest.IntMaps{},
+			b: &pb3_latest.IntMaps{IntToString: map[int64]string{10: "ten", 9: "nine"}},
+			want: [][3]string{
+				{"int_to_string", "", `{9: "nine", 10: "ten"}`},
+			},
+		},
+		"map entry": {
+			a: &pb3_latest.IntMaps{IntToString: map[int64]string{10: "ten", 9: "nine"}},
+			b: &pb3_latest.IntMaps{IntToString: map[int64]string{10: "TEN", 9: "nine"}},
+			want: [][3]string{
+				{"int_to_string[10]", `"ten"`, `"TEN"`},
+			},
+		},
+		"well-known type": {
+			a: &pb3_latest.KnownTypes{TimestampField: &timestamppb.Timestamp{Seconds: 1}},
+			b: &pb3_latest.KnownTypes{TimestampField: &timestamppb.Timestamp{Seconds: 2}},
+			want: [][3]string{
+				{"timestamp_field", `"1970-01-01T00:00:01Z"`, `"1970-01-01T00:00:02Z"`},
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			var paths []string
+			var trees []*protoreflecthash.HashNode
+			for i, msg := range []proto.Message{tc.a, tc.b} {
+				path := filepath.Join(dir, fmt.Sprintf("%d.textproto", i))
+				data, err := prototext.Marshal(msg)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := ioutil.WriteFile(path, data, 0644); err != nil {
+					t.Fatal(err)
+				}
+				tree, err := protoreflecthash.NewHasher().(protoreflecthash.TreeProtoHasher).HashTree(msg.ProtoReflect())
+				if err != nil {
+					t.Fatal(err)
+				}
+				paths = append(paths, path)
+				trees = append(trees, tree)
+			}
+
+			var want strings.Builder
+			if len(tc.want) > 0 {
+				fmt.Fprintf(&want, "--- %s\n+++ %s\n", paths[0], paths[1])
+			}
+			for _, d := range tc.want {
+				fmt.Fprintln(&want, d[0])
+				for i, sign := range []string{"-", "+"} {
+					if d[i+1] == "" {
+						fmt.Fprintf(&want, "%s <absent>\n", sign)
+					} else {
+						fmt.Fprintf(&want, "%s %s  %s\n", sign, findNode(trees[i], d[0]).Hash, d[i+1])
+					}
+				}
+			}
+			wantStatus := 0
+			if len(tc.want) > 0 {
+				wantStatus = 1
+			}
+
+			typeName := string(tc.a.ProtoReflect().Descriptor().FullName())
+			var stdout, stderr bytes.Buffer
+			status := run(append([]string{"diff", "-protoset", protoset, "-type", typeName}, paths...), nil, &stdout, &stderr)
+			if status != wantStatus {
+				t.Errorf("want status %d, got %d: %s", wantStatus, status, stderr.String())
+			}
+			if got := stdout.String(); got != want.String() {
+				t.Errorf("want output\n%s\ngot\n%s", want.String(), got)
+			}
+		})
+	}
+}
+
+func TestDiffErrors(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.json")
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := ioutil.WriteFile(valid, []byte(`{"id": 1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(invalid, []byte(`{"id": "x"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, tc := range map[string]struct {
+		files  []string
+		stderr string
+	}{
+		"one file":        {[]string{valid}, "usage: protohash diff"},
+		"three files":     {[]string{valid, valid, valid}, "usage: protohash diff"},
+		"invalid message": {[]string{valid, invalid}, "invalid.json: parsing json message"},
+	} {
+		var stdout, stderr bytes.Buffer
+		args := append([]string{"diff", "-protoset", protoset, "-type", "schema.proto3.PersonV4"}, tc.files...)
+		if status := run(args, nil, &stdout, &stderr); status != 2 {
+			t.Errorf("%s: want status 2, got %d", name, status)
+		}
+		if !strings.Contains(stderr.String(), tc.stderr) {
+			t.Errorf("%s: want standard error containing %q, got %q", name, tc.stderr, stderr.String())
+		}
+	}
+}
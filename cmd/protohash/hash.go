@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+)
+
+// runHash prints the hash of the message read from each file given as
+// argument, or from the standard input, in the format of sha256sum:
+//
+//	<hex hash>  <path>
+func runHash(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("hash", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var c config
+	c.register(fs)
+	c.registerFormat(fs)
+	c.registerType(fs, "fully-qualified `name` of the message type (required)")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: protohash hash -protoset FILE -type NAME [flags] [FILE...]\n\n")
+		fmt.Fprintf(stderr, "Prints the hash of the message in each file, or in the standard input (\"-\").\n\nflags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	h, md, err := c.setup()
+	if err != nil {
+		fmt.Fprintf(stderr, "protohash: %v\n", err)
+		return 2
+	}
+
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"-"}
+	}
+	status := 0
+	for _, path := range paths {
+		data, err := readFile(path, stdin)
+		if err == nil {
+			var hash []byte
+			if hash, err = c.hash(h, md, path, data); err == nil {
+				fmt.Fprintf(stdout, "%x  %s\n", hash, path)
+				continue
+			}
+		}
+		fmt.Fprintf(stderr, "protohash: %s: %v\n", path, err)
+		status = 1
+	}
+	return status
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/stackb/protoreflecthash"
+	pb2_latest "github.com/stackb/protoreflecthash/test_protos/generated/latest/proto2"
+	pb3_latest "github.com/stackb/protoreflecthash/test_protos/generated/latest/proto3"
+)
+
+const protoset = "../../testdata/protoset.pb"
+
+// knownTypes returns a message exercising the resolution of types by the
+// command: it holds an Any and other well-known types.
+func knownTypes(t *testing.T) *pb3_latest.KnownTypes {
+	t.Helper()
+	packed, err := anypb.New(&pb3_latest.Simple{StringField: "packed", Int32Field: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := structpb.NewStruct(map[string]interface{}{"a": 1.0, "b": []interface{}{"c", true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &pb3_latest.KnownTypes{
+		AnyField:       packed,
+		StructField:    st,
+		TimestampField: &timestamppb.Timestamp{Seconds: 1700000000, Nanos: 5},
+	}
+}
+
+// writeMessage writes msg in every format to dir and returns the paths of the
+// files.
+func writeMessage(t *testing.T, dir string, msg proto.Message) []string {
+	t.Helper()
+	binary, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	json, err := protojson.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := prototext.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for name, data := range map[string][]byte{
+		"msg.pb":        binary,
+		"msg.json":      json,
+		"msg.textproto": text,
+	} {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestHash(t *testing.T) {
+	dir := t.TempDir()
+	msg := knownTypes(t)
+	paths := writeMessage(t, dir, msg)
+	keyFile := filepath.Join(dir, "key")
+	if err := ioutil.WriteFile(keyFile, []byte("key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, tc := range map[string]struct {
+		flags   []string
+		options []protoreflecthash.Option
+	}{
+		"default": {},
+		"fullname identifier": {
+			flags:   []string{"-fullname_identifier"},
+			options: []protoreflecthash.Option{protoreflecthash.MessageFullnameIdentifier()},
+		},
+		"field names": {
+			flags:   []string{"-field_names"},
+			options: []protoreflecthash.Option{protoreflecthash.FieldNamesAsKeys()},
+		},
+		"blake3 hmac": {
+			flags:   []string{"-hash", "blake3", "-hmac_key_file", keyFile, "-hmac_key_id", "k", "-hmac_scope", "all"},
+			options: []protoreflecthash.Option{protoreflecthash.BLAKE3(), protoreflecthash.HMAC("k", []byte("key"), protoreflecthash.KeyAllNodes)},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			hash, err := protoreflecthash.NewHasher(tc.options...).HashProto(msg.ProtoReflect())
+			if err != nil {
+				t.Fatal(err)
+			}
+			var want strings.Builder
+			for _, path := range paths {
+				fmt.Fprintf(&want, "%x  %s\n", hash, path)
+			}
+
+			args := append([]string{"hash", "-protoset", protoset, "-type", "schema.proto3.KnownTypes"}, tc.flags...)
+			var stdout, stderr bytes.Buffer
+			if status := run(append(args, paths...), nil, &stdout, &stderr); status != 0 {
+				t.Fatalf("want status 0, got %d: %s", status, stderr.String())
+			}
+			if got := stdout.String(); got != want.String() {
+				t.Errorf("want output\n%s\ngot\n%s", want.String(), got)
+			}
+		})
+	}
+}
+
+func TestHashStdin(t *testing.T) {
+	msg := &pb3_latest.Simple{StringField: "stdin"}
+	hash, err := protoreflecthash.NewHasher().HashProto(msg.ProtoReflect())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for format, marshal := range map[string]func(proto.Message) ([]byte, error){
+		"auto":   proto.Marshal,
+		"binary": proto.Marshal,
+		"json":   protojson.Marshal,
+		"text":   prototext.Marshal,
+	} {
+		data, err := marshal(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var stdout, stderr bytes.Buffer
+		args := []string{"hash", "-protoset", protoset, "-type", "schema.proto3.Simple", "-format", format}
+		if status := run(args, bytes.NewReader(data), &stdout, &stderr); status != 0 {
+			t.Fatalf("%s: want status 0, got %d: %s", format, status, stderr.String())
+		}
+		if got, want := stdout.String(), fmt.Sprintf("%x  -\n", hash); got != want {
+			t.Errorf("%s: want output %q, got %q", format, want, got)
+		}
+	}
+}
+
+func TestHashValidation(t *testing.T) {
+	// An empty message lacks the required text field.
+	msg := &pb2_latest.BadWithRequirements{}
+	dir := t.TempDir()
+	var paths []string
+	for name, data := range map[string]string{
+		"msg.pb":        "",
+		"msg.json":      "{}",
+		"msg.textproto": "",
+	} {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	args := []string{"hash", "-protoset", protoset, "-type", "schema.proto2.BadWithRequirements"}
+
+	// Without validation, the partial message is hashed alike in every format.
+	hash, err := protoreflecthash.NewHasher(protoreflecthash.Validation(protoreflecthash.ValidateNone)).HashProto(msg.ProtoReflect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		var stdout, stderr bytes.Buffer
+		if status := run(append(args, "-validation", "none", path), nil, &stdout, &stderr); status != 0 {
+			t.Fatalf("%s: want status 0, got %d: %s", path, status, stderr.String())
+		}
+		if got, want := stdout.String(), fmt.Sprintf("%x  %s\n", hash, path); got != want {
+			t.Errorf("%s: want output %q, got %q", path, want, got)
+		}
+	}
+
+	// By default, it is rejected alike in every format.
+	for _, path := range paths {
+		var stdout, stderr bytes.Buffer
+		if status := run(append(args, path), nil, &stdout, &stderr); status != 1 {
+			t.Errorf("%s: want status 1, got %d", path, status)
+		}
+		if want := "invalid message"; !strings.Contains(stderr.String(), want) {
+			t.Errorf("%s: want standard error containing %q, got %q", path, want, stderr.String())
+		}
+	}
+}
+
+func TestHashErrors(t *testing.T) {
+	dir := t.TempDir()
+	invalid := filepath.Join(dir, "invalid.json")
+	if err := ioutil.WriteFile(invalid, []byte(`{"no_such_field": 1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, tc := range map[string]struct {
+		args   []string
+		status int
+		// stderr is a substring of the standard error.
+		stderr string
+	}{
+		"no command": {
+			status: 2,
+			stderr: "usage: protohash",
+		},
+		"unknown command": {
+			args:   []string{"frobnicate"},
+			status: 2,
+			stderr: `unknown command "frobnicate"`,
+		},
+		"no protoset": {
+			args:   []string{"hash", "-type", "schema.proto3.Simple"},
+			status: 2,
+			stderr: "-protoset is required",
+		},
+		"unknown type": {
+			args:   []string{"hash", "-protoset", protoset, "-type", "schema.proto3.Nope"},
+			status: 2,
+			stderr: "unknown message type schema.proto3.Nope",
+		},
+		"not a message type": {
+			args:   []string{"hash", "-protoset", protoset, "-type", "schema.proto3.Simple.string_field"},
+			status: 2,
+			stderr: "schema.proto3.Simple.string_field is not a message type",
+		},
+		"invalid option": {
+			args:   []string{"hash", "-protoset", protoset, "-type", "schema.proto3.Simple", "-validation", "lax"},
+			status: 2,
+			stderr: `invalid -validation "lax" (want required, none or strict)`,
+		},
+		"missing file": {
+			args:   []string{"hash", "-protoset", protoset, "-type", "schema.proto3.Simple", filepath.Join(dir, "missing.pb")},
+			status: 1,
+			stderr: "no such file or directory",
+		},
+		"invalid message": {
+			args:   []string{"hash", "-protoset", protoset, "-type", "schema.proto3.Simple", invalid},
+			status: 1,
+			stderr: "parsing json message",
+		},
+	} {
+		var stdout, stderr bytes.Buffer
+		if status := run(tc.args, nil, &stdout, &stderr); status != tc.status {
+			t.Errorf("%s: want status %d, got %d", name, tc.status, status)
+		}
+		if !strings.Contains(stderr.String(), tc.stderr) {
+			t.Errorf("%s: want standard error containing %q, got %q", name, tc.stderr, stderr.String())
+		}
+	}
+}
@@ -0,0 +1,74 @@
+// Command protohash computes the object hashes of protobuf messages, whose
+// types are described by a FileDescriptorSet such as the one written by
+// `protoc --include_imports --descriptor_set_out`.
+//
+// Usage:
+//
+//	protohash <command> [flags] [arguments]
+//
+// The commands are:
+//
+//	hash    print the hashes of messages
+//	diff    print the paths at which two messages differ by hash
+//	sum     print a manifest of the hashes of message files
+//	check   check message files against a manifest
+//	serve   serve the hashing of messages over HTTP
+//
+// Run `protohash <command> -h` for the flags of a command.
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// command is a subcommand of protohash.
+type command struct {
+	name     string
+	synopsis string
+	// run runs the command with the given arguments and returns the exit
+	// status.
+	run func(args []string, stdin io.Reader, stdout, stderr io.Writer) int
+}
+
+var commands = []command{
+	{name: "hash", synopsis: "print the hashes of messages", run: runHash},
+	{name: "diff", synopsis: "print the paths at which two messages differ by hash", run: runDiff},
+	{name: "sum", synopsis: "print a manifest of the hashes of message files", run: runSum},
+	{name: "check", synopsis: "check message files against a manifest", run: runCheck},
+	{name: "serve", synopsis: "serve the hashing of messages over HTTP", run: runServe},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command named by the first argument and returns the exit
+// status: 0 on success, 1 on failure and 2 on invalid usage.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		usage(stderr)
+		return 2
+	}
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:], stdin, stdout, stderr)
+		}
+	}
+	if args[0] == "help" || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+		usage(stdout)
+		return 0
+	}
+	fmt.Fprintf(stderr, "protohash: unknown command %q\n", args[0])
+	usage(stderr)
+	return 2
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "usage: protohash <command> [flags] [arguments]\n\ncommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.name, cmd.synopsis)
+	}
+	fmt.Fprintf(w, "\nRun 'protohash <command> -h' for the flags of a command.\n")
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/stackb/protoreflecthash/server"
+)
+
+// runServe serves the hashing of messages over HTTP, as described in package
+// server, until interrupted.
+func runServe(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	var c config
+	c.register(flags)
+	addr := flags.String("addr", "localhost:8080", "`address` to listen on")
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "usage: protohash serve -protoset FILE [-addr ADDRESS] [flags]\n\n")
+		fmt.Fprintf(stderr, "Serves the hashing of the messages posted to %s<type>, in protojson or\nbinary form.\n\nflags:\n", server.HashPath)
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return 2
+	}
+
+	if err := c.load(); err != nil {
+		fmt.Fprintf(stderr, "protohash: %v\n", err)
+		return 2
+	}
+	options, err := c.options()
+	if err != nil {
+		fmt.Fprintf(stderr, "protohash: %v\n", err)
+		return 2
+	}
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Fprintf(stderr, "protohash: %v\n", err)
+		return 1
+	}
+	srv := &http.Server{Handler: server.New(c.files, options...)}
+	fmt.Fprintf(stderr, "protohash: serving on http://%s%s\n", ln.Addr(), server.HashPath)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.Serve(ln)
+	}()
+	select {
+	case err := <-errc:
+		fmt.Fprintf(stderr, "protohash: %v\n", err)
+		return 1
+	case <-ctx.Done():
+		if err := srv.Shutdown(context.Background()); err != nil {
+			fmt.Fprintf(stderr, "protohash: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+}
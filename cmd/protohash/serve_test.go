@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestServeErrors(t *testing.T) {
+	for name, tc := range map[string]struct {
+		args   []string
+		status int
+		stderr string
+	}{
+		"arguments": {
+			args:   []string{"serve", "-protoset", protoset, "extra"},
+			status: 2,
+			stderr: "usage: protohash serve",
+		},
+		"no protoset": {
+			args:   []string{"serve"},
+			status: 2,
+			stderr: "-protoset is required",
+		},
+		"invalid option": {
+			args:   []string{"serve", "-protoset", protoset, "-hash", "md5"},
+			status: 2,
+			stderr: `invalid -hash "md5"`,
+		},
+		"invalid address": {
+			args:   []string{"serve", "-protoset", protoset, "-addr", "localhost:-1"},
+			status: 1,
+			stderr: "invalid port",
+		},
+	} {
+		var stdout, stderr bytes.Buffer
+		if status := run(tc.args, nil, &stdout, &stderr); status != tc.status {
+			t.Errorf("%s: want status %d, got %d", name, tc.status, status)
+		}
+		if !strings.Contains(stderr.String(), tc.stderr) {
+			t.Errorf("%s: want standard error containing %q, got %q", name, tc.stderr, stderr.String())
+		}
+	}
+}
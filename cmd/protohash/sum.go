@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	iofs "io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/stackb/protoreflecthash"
+)
+
+// messageTypeHeader is the comment by which a textproto file declares the type
+// of its message, as in:
+//
+//	# proto-message: example.Config
+const messageTypeHeader = "proto-message:"
+
+// manifestEntry is a line of a manifest:
+//
+//	<hex hash> <type> <path>
+//
+// The path is slash-separated, so that manifests are portable.
+type manifestEntry struct {
+	hash     string
+	typeName string
+	path     string
+}
+
+// runSum walks the files and directories given as arguments and prints a
+// manifest line for each message file: the files having the extension of a
+// message format (.json, .textproto, .txtpb, .pbtxt, .prototxt, .pb and
+// .binpb) and any file given explicitly.
+func runSum(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("sum", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var c config
+	c.register(fs)
+	c.registerFormat(fs)
+	c.registerType(fs, "fully-qualified `name` of the message type of the files, unless declared by a \"# proto-message:\" header")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: protohash sum -protoset FILE [-type NAME] [flags] FILE_OR_DIR...\n\n")
+		fmt.Fprintf(stderr, "Prints a manifest of the hashes of the message files found in the given files\nand directories, as lines of \"<hash> <type> <path>\".\n\nflags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	h, err := c.hasher()
+	if err != nil {
+		fmt.Fprintf(stderr, "protohash: %v\n", err)
+		return 2
+	}
+
+	status := 0
+	for _, root := range fs.Args() {
+		paths, err := messageFiles(root)
+		if err != nil {
+			fmt.Fprintf(stderr, "protohash: %v\n", err)
+			status = 1
+		}
+		for _, path := range paths {
+			entry, err := c.sum(h, path, "")
+			if err != nil {
+				fmt.Fprintf(stderr, "protohash: %s: %v\n", path, err)
+				status = 1
+				continue
+			}
+			fmt.Fprintf(stdout, "%s %s %s\n", entry.hash, entry.typeName, entry.path)
+		}
+	}
+	return status
+}
+
+// runCheck reads a manifest written by sum and re-hashes the files it lists,
+// printing for each whether its hash is the same (OK), differs (FAILED) or
+// whether the file is MISSING.  Files of the directories given as arguments
+// that are not in the manifest are reported as NOT IN MANIFEST.
+func runCheck(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var c config
+	c.register(fs)
+	c.registerFormat(fs)
+	quiet := fs.Bool("quiet", false, "don't print OK for each file hashing as in the manifest")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: protohash check -protoset FILE [flags] MANIFEST [DIR...]\n\n")
+		fmt.Fprintf(stderr, "Re-hashes the files listed in a manifest (\"-\" for the standard input) and\nreports those whose hash differs, those missing and the message files of the\ngiven directories that are not in the manifest.\n\nflags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+
+	h, err := c.hasher()
+	if err != nil {
+		fmt.Fprintf(stderr, "protohash: %v\n", err)
+		return 2
+	}
+	manifest, err := readManifest(fs.Arg(0), stdin)
+	if err != nil {
+		fmt.Fprintf(stderr, "protohash: %v\n", err)
+		return 2
+	}
+
+	var failed, missing, unlisted int
+	listed := make(map[string]bool, len(manifest))
+	for _, want := range manifest {
+		path := filepath.FromSlash(want.path)
+		listed[filepath.Clean(path)] = true
+
+		got, err := c.sum(h, path, want.typeName)
+		switch {
+		case errors.Is(err, iofs.ErrNotExist):
+			fmt.Fprintf(stdout, "%s: MISSING\n", want.path)
+			missing++
+		case err != nil:
+			fmt.Fprintf(stdout, "%s: FAILED\n", want.path)
+			fmt.Fprintf(stderr, "protohash: %s: %v\n", want.path, err)
+			failed++
+		case got.hash != want.hash:
+			fmt.Fprintf(stdout, "%s: FAILED\n", want.path)
+			failed++
+		case !*quiet:
+			fmt.Fprintf(stdout, "%s: OK\n", want.path)
+		}
+	}
+
+	status := 0
+	for _, dir := range fs.Args()[1:] {
+		paths, err := messageFiles(dir)
+		if err != nil {
+			fmt.Fprintf(stderr, "protohash: %v\n", err)
+			status = 1
+		}
+		for _, path := range paths {
+			if !listed[filepath.Clean(path)] {
+				fmt.Fprintf(stdout, "%s: NOT IN MANIFEST\n", filepath.ToSlash(path))
+				unlisted++
+			}
+		}
+	}
+
+	if failed > 0 {
+		fmt.Fprintf(stderr, "protohash: WARNING: %d of %d files did NOT match\n", failed, len(manifest))
+	}
+	if missing > 0 {
+		fmt.Fprintf(stderr, "protohash: WARNING: %d listed files are missing\n", missing)
+	}
+	if unlisted > 0 {
+		fmt.Fprintf(stderr, "protohash: WARNING: %d files are not in the manifest\n", unlisted)
+	}
+	if failed > 0 || missing > 0 || unlisted > 0 {
+		status = 1
+	}
+	return status
+}
+
+// sum hashes the message file at path and returns its manifest entry.  The
+// message type is typeName if not empty, and otherwise the one declared by the
+// file or set by -type.
+func (c *config) sum(h protoreflecthash.ProtoHasher, path, typeName string) (*manifestEntry, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if typeName == "" {
+		if typeName, err = c.fileType(path, data); err != nil {
+			return nil, err
+		}
+	}
+	md, err := c.messageType(typeName)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := c.hash(h, md, path, data)
+	if err != nil {
+		return nil, err
+	}
+	return &manifestEntry{
+		hash:     fmt.Sprintf("%x", hash),
+		typeName: typeName,
+		path:     filepath.ToSlash(path),
+	}, nil
+}
+
+// fileType returns the message type of the file at path: the one declared by
+// its "# proto-message:" header, for textproto files, or the one set by -type.
+func (c *config) fileType(path string, data []byte) (string, error) {
+	format, err := c.formatOf(path)
+	if err != nil {
+		return "", err
+	}
+	if format == formatText {
+		if typeName := declaredType(data); typeName != "" {
+			return typeName, nil
+		}
+	}
+	if c.typeName == "" {
+		return "", errors.New("no message type: set -type or declare it with a \"# proto-message:\" header")
+	}
+	return c.typeName, nil
+}
+
+// declaredType returns the message type declared by the "# proto-message:"
+// header of a textproto file, which must be among the comments at its start.
+func declaredType(data []byte) string {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "#") {
+			break
+		}
+		comment := strings.TrimSpace(strings.TrimPrefix(line, "#"))
+		if strings.HasPrefix(comment, messageTypeHeader) {
+			return strings.TrimSpace(strings.TrimPrefix(comment, messageTypeHeader))
+		}
+	}
+	return ""
+}
+
+// messageFiles returns root if it is a file, or the message files within root
+// if it is a directory, in lexical order.
+func messageFiles(root string) ([]string, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{root}, nil
+	}
+
+	var paths []string
+	err = filepath.WalkDir(root, func(path string, d iofs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() && isMessageFile(path) {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	return paths, err
+}
+
+// isMessageFile reports whether the extension of path is the one of a message
+// format.
+func isMessageFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	return ext == ".json" || textExtensions[ext] || binaryExtensions[ext]
+}
+
+// readManifest reads the manifest at path, "-" being the standard input.
+func readManifest(path string, stdin io.Reader) ([]*manifestEntry, error) {
+	data, err := readFile(path, stdin)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest []*manifestEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		// The path is last, so that it may contain spaces.
+		fields := strings.SplitN(line, " ", 3)
+		if len(fields) != 3 || fields[0] == "" || fields[1] == "" || fields[2] == "" {
+			return nil, fmt.Errorf("%s:%d: malformed manifest line", path, n)
+		}
+		manifest = append(manifest, &manifestEntry{hash: fields[0], typeName: fields[1], path: fields[2]})
+	}
+	return manifest, nil
+}
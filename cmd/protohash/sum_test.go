@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/stackb/protoreflecthash"
+	pb3_latest "github.com/stackb/protoreflecthash/test_protos/generated/latest/proto3"
+)
+
+// writeFiles writes the files of a directory tree, given by slash-separated
+// path.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// hashOf returns the hex hash of msg.
+func hashOf(t *testing.T, msg proto.Message) string {
+	t.Helper()
+	hash, err := protoreflecthash.NewHasher().HashProto(msg.ProtoReflect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fmt.Sprintf("%x", hash)
+}
+
+func TestSumAndCheck(t *testing.T) {
+	dir := t.TempDir()
+	binary, err := proto.Marshal(&pb3_latest.Simple{Int32Field: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, dir, map[string]string{
+		"a.textproto":      "# proto-file: people.proto\n# proto-message: schema.proto3.PersonV4\n\nid: 1\nprofession: \"engineer\"\n",
+		"b.json":           `{"stringField": "b"}`,
+		"nested/c.pb":      string(binary),
+		"nested/README.md": "not a message",
+	})
+	path := func(name string) string {
+		return filepath.ToSlash(filepath.Join(dir, filepath.FromSlash(name)))
+	}
+
+	// sum
+	var stdout, stderr bytes.Buffer
+	args := []string{"sum", "-protoset", protoset, "-type", "schema.proto3.Simple", dir}
+	if status := run(args, nil, &stdout, &stderr); status != 0 {
+		t.Fatalf("sum: want status 0, got %d: %s", status, stderr.String())
+	}
+	manifest := stdout.String()
+	want := strings.Join([]string{
+		hashOf(t, &pb3_latest.PersonV4{Id: 1, Profession: "engineer"}) + " schema.proto3.PersonV4 " + path("a.textproto"),
+		hashOf(t, &pb3_latest.Simple{StringField: "b"}) + " schema.proto3.Simple " + path("b.json"),
+		hashOf(t, &pb3_latest.Simple{Int32Field: 7}) + " schema.proto3.Simple " + path("nested/c.pb"),
+	}, "\n") + "\n"
+	if manifest != want {
+		t.Fatalf("sum: want manifest\n%s\ngot\n%s", want, manifest)
+	}
+
+	check := func(wantStatus int, want string) {
+		t.Helper()
+		var stdout, stderr bytes.Buffer
+		args := []string{"check", "-protoset", protoset, "-", dir}
+		if status := run(args, strings.NewReader(manifest), &stdout, &stderr); status != wantStatus {
+			t.Errorf("check: want status %d, got %d: %s", wantStatus, status, stderr.String())
+		}
+		if got := stdout.String(); got != want {
+			t.Errorf("check: want output\n%s\ngot\n%s", want, got)
+		}
+	}
+
+	check(0, path("a.textproto")+": OK\n"+path("b.json")+": OK\n"+path("nested/c.pb")+": OK\n")
+
+	// Reformatting a file does not change its hash.
+	writeFiles(t, dir, map[string]string{
+		"a.textproto": "# proto-message: schema.proto3.PersonV4\nprofession:   'engineer'\n# The id.\nid: 0x1\n",
+		"b.json":      "{\n  \"string_field\": \"b\"\n}\n",
+	})
+	check(0, path("a.textproto")+": OK\n"+path("b.json")+": OK\n"+path("nested/c.pb")+": OK\n")
+
+	// Changing, removing or adding files does.
+	writeFiles(t, dir, map[string]string{
+		"b.json":        `{"stringField": "c"}`,
+		"nested/d.json": `{}`,
+	})
+	if err := os.Remove(filepath.Join(dir, "nested", "c.pb")); err != nil {
+		t.Fatal(err)
+	}
+	check(1, path("a.textproto")+": OK\n"+path("b.json")+": FAILED\n"+path("nested/c.pb")+": MISSING\n"+path("nested/d.json")+": NOT IN MANIFEST\n")
+}
+
+func TestSumErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"untyped.textproto": "id: 1\n",
+		"untyped.json":      `{"id": 1}`,
+	})
+
+	var stdout, stderr bytes.Buffer
+	if status := run([]string{"sum", "-protoset", protoset, dir}, nil, &stdout, &stderr); status != 1 {
+		t.Errorf("want status 1, got %d", status)
+	}
+	for _, want := range []string{
+		"untyped.json: no message type: set -type or declare it",
+		"untyped.textproto: no message type: set -type or declare it",
+	} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("want standard error containing %q, got %q", want, stderr.String())
+		}
+	}
+
+	stderr.Reset()
+	manifest := strings.NewReader("0123 schema.proto3.Simple\n")
+	if status := run([]string{"check", "-protoset", protoset, "-"}, manifest, &stdout, &stderr); status != 2 {
+		t.Errorf("want status 2, got %d", status)
+	}
+	if want := "-:1: malformed manifest line"; !strings.Contains(stderr.String(), want) {
+		t.Errorf("want standard error containing %q, got %q", want, stderr.String())
+	}
+}
+
+func TestDeclaredType(t *testing.T) {
+	for name, tc := range map[string]struct {
+		text, want string
+	}{
+		"header":            {"# proto-message: a.B\nid: 1\n", "a.B"},
+		"after other":       {"# proto-file: a.proto\n#proto-message:a.B\n", "a.B"},
+		"after blank lines": {"\n\n  # proto-message: a.B\n", "a.B"},
+		"none":              {"# a comment\nid: 1\n", ""},
+		"after fields":      {"id: 1\n# proto-message: a.B\n", ""},
+	} {
+		if got := declaredType([]byte(tc.text)); got != tc.want {
+			t.Errorf("%s: want %q, got %q", name, tc.want, got)
+		}
+	}
+}
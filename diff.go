@@ -0,0 +1,176 @@
+package protoreflecthash
+
+import (
+	"bytes"
+	"fmt"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// Diff is the difference between two messages of the same type.
+type Diff struct {
+	// Paths are the paths of the values that differ, in canonical order.  A
+	// value that differs in only one place is descended into, so that the
+	// paths are as deep as possible, except for well-known types, which are
+	// compared as a whole.  A value present in only one of the messages is
+	// reported by its own path.  Differences that cannot be attributed to a
+	// field, such as those in unknown fields, are reported by the path of the
+	// message they belong to, which is empty for the root.
+	Paths []string
+	// FieldMask holds the paths of the fields that differ, up to the first list
+	// index or map key, as field masks cannot address list elements and map
+	// entries.  It is normalized: sorted, without duplicates or paths covered
+	// by others.
+	FieldMask *fieldmaskpb.FieldMask
+}
+
+// DiffingProtoHasher is implemented by the ProtoHasher returned by NewHasher.
+type DiffingProtoHasher interface {
+	ProtoHasher
+	// Diff returns the difference between two messages of the same type,
+	// found by comparing their hash trees top-down and only descending into
+	// subtrees whose hashes differ.
+	Diff(a, b protoreflect.Message) (*Diff, error)
+}
+
+// Diff implements DiffingProtoHasher.
+func (h *hasher) Diff(a, b protoreflect.Message) (*Diff, error) {
+	if a == nil || b == nil {
+		return nil, fmt.Errorf("cannot diff a nil message")
+	}
+	if a.Descriptor().FullName() != b.Descriptor().FullName() {
+		return nil, fmt.Errorf("cannot diff %s against %s", a.Descriptor().FullName(), b.Descriptor().FullName())
+	}
+
+	treeA, err := h.HashTree(a)
+	if err != nil {
+		return nil, err
+	}
+	treeB, err := h.HashTree(b)
+	if err != nil {
+		return nil, err
+	}
+
+	return DiffTrees(treeA, treeB), nil
+}
+
+// DiffTrees returns the difference between two messages of the same type from
+// their hash trees, as returned by HashTree with the same options.  It is the
+// difference Diff returns, for callers that already hold the trees.
+func DiffTrees(a, b *HashNode) *Diff {
+	d := &differ{seen: make(map[string]bool)}
+	d.diff(a, b)
+
+	var mask []string
+	for _, path := range d.paths {
+		if path != "" {
+			mask = append(mask, fieldMaskPath(MustParsePath(path)))
+		}
+	}
+
+	return &Diff{
+		Paths:     d.paths,
+		FieldMask: &fieldmaskpb.FieldMask{Paths: normalizeFieldMaskPaths(mask)},
+	}
+}
+
+// differ collects the paths of the differences between two hash trees.
+type differ struct {
+	paths []string
+	seen  map[string]bool
+}
+
+// report records a difference at the given path, once.
+func (d *differ) report(path string) {
+	if !d.seen[path] {
+		d.seen[path] = true
+		d.paths = append(d.paths, path)
+	}
+}
+
+// diff compares two nodes having the same path.
+func (d *differ) diff(a, b *HashNode) {
+	if bytes.Equal(a.Hash, b.Hash) {
+		return
+	}
+	if a.Identifier != b.Identifier || isLeafIdentifier(a.Identifier) || a.Identifier == setIdentifier {
+		d.report(a.Path)
+		return
+	}
+
+	if a.Identifier == listIdentifier {
+		n := len(a.Children)
+		if len(b.Children) > n {
+			n = len(b.Children)
+		}
+		for i := 0; i < n; i++ {
+			switch {
+			case i >= len(a.Children):
+				d.reportChild(b, b.Children[i])
+			case i >= len(b.Children):
+				d.reportChild(a, a.Children[i])
+			default:
+				d.diffChildren(a, a.Children[i], b.Children[i])
+			}
+		}
+		return
+	}
+
+	// Entries of messages and maps are matched by key hash.  Both are in
+	// canonical order, but of different kinds (field numbers and key hashes),
+	// so the entries of a are looked up in those of b rather than merged.
+	inA := make(map[string]bool, len(a.Children))
+	byKey := make(map[string]*HashNode, len(b.Children))
+	for _, child := range b.Children {
+		byKey[string(child.KeyHash)] = child
+	}
+	for _, child := range a.Children {
+		inA[string(child.KeyHash)] = true
+		if other, ok := byKey[string(child.KeyHash)]; ok {
+			d.diffChildren(a, child, other)
+		} else {
+			d.reportChild(a, child)
+		}
+	}
+	for _, child := range b.Children {
+		if !inA[string(child.KeyHash)] {
+			d.reportChild(b, child)
+		}
+	}
+}
+
+// diffChildren compares two children of the given parent.  Children without a
+// path, such as those of well-known types, are reported by the path of the
+// parent.
+func (d *differ) diffChildren(parent, a, b *HashNode) {
+	if bytes.Equal(a.Hash, b.Hash) {
+		return
+	}
+	if a.Path == "" || b.Path == "" {
+		d.report(parent.Path)
+		return
+	}
+	d.diff(a, b)
+}
+
+// reportChild records a difference at the path of a child of the given parent
+// present in only one of the trees.
+func (d *differ) reportChild(parent, child *HashNode) {
+	if child.Path == "" {
+		d.report(parent.Path)
+		return
+	}
+	d.report(child.Path)
+}
+
+// fieldMaskPath returns the field mask path of the given path, which is made
+// of its field names up to the first list index or map key.
+func fieldMaskPath(path Path) string {
+	for i, step := range path {
+		if step.Key != nil {
+			return path[:i].String()
+		}
+	}
+	return path.String()
+}
@@ -0,0 +1,175 @@
+package protoreflecthash
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb3_latest "github.com/stackb/protoreflecthash/test_protos/generated/latest/proto3"
+)
+
+func TestDiff(t *testing.T) {
+	person := func() *pb3_latest.PersonV4 {
+		return &pb3_latest.PersonV4{
+			Id:             1,
+			Age:            30,
+			StructuredName: &pb3_latest.PersonV4_NameV4{First: "Ada", Last: "Lovelace"},
+			Children: []*pb3_latest.PersonV3{
+				{Id: 2, Age: 3},
+				{Id: 3, Age: 4},
+			},
+		}
+	}
+	maps := func() *pb3_latest.StringMaps {
+		return &pb3_latest.StringMaps{
+			StringToString: map[string]string{"a": "x", "b": "y"},
+			StringToInt32:  map[string]int32{"a": 1},
+		}
+	}
+	known := func() *pb3_latest.KnownTypes {
+		return &pb3_latest.KnownTypes{
+			StructField:    &structpb.Struct{Fields: map[string]*structpb.Value{"a": structpb.NewNumberValue(1)}},
+			TimestampField: &timestamppb.Timestamp{Seconds: 1},
+		}
+	}
+
+	for name, tc := range map[string]struct {
+		options []Option
+		a, b    proto.Message
+		paths   []string
+		mask    []string
+	}{
+		"equal": {
+			a: person(),
+			b: person(),
+		},
+		"leaf": {
+			a:     person(),
+			b:     func() proto.Message { p := person(); p.Age = 31; return p }(),
+			paths: []string{"age"},
+			mask:  []string{"age"},
+		},
+		"nested field": {
+			a:     person(),
+			b:     func() proto.Message { p := person(); p.StructuredName.Last = "Byron"; return p }(),
+			paths: []string{"structured_name.last"},
+			mask:  []string{"structured_name.last"},
+		},
+		"added and removed fields": {
+			a:     person(),
+			b:     func() proto.Message { p := person(); p.Age = 0; p.Profession = "mathematician"; return p }(),
+			paths: []string{"age", "profession"},
+			mask:  []string{"age", "profession"},
+		},
+		"list elements": {
+			a: person(),
+			b: func() proto.Message {
+				p := person()
+				p.Children[1].Age = 5
+				p.Children = append(p.Children, &pb3_latest.PersonV3{Id: 4})
+				return p
+			}(),
+			paths: []string{"children[1].age", "children[2]"},
+			mask:  []string{"children"},
+		},
+		"removed list elements": {
+			a:     person(),
+			b:     func() proto.Message { p := person(); p.Children = p.Children[:1]; return p }(),
+			paths: []string{"children[1]"},
+			mask:  []string{"children"},
+		},
+		"map entries": {
+			a: maps(),
+			b: func() proto.Message {
+				m := maps()
+				m.StringToString["b"] = "z"
+				delete(m.StringToString, "a")
+				m.StringToString["c"] = "x"
+				return m
+			}(),
+			paths: []string{`string_to_string["a"]`, `string_to_string["b"]`, `string_to_string["c"]`},
+			mask:  []string{"string_to_string"},
+		},
+		"several fields of a map": {
+			a:     maps(),
+			b:     func() proto.Message { m := maps(); m.StringToInt32["a"] = 2; m.StringToString["a"] = "w"; return m }(),
+			paths: []string{`string_to_int32["a"]`, `string_to_string["a"]`},
+			mask:  []string{"string_to_int32", "string_to_string"},
+		},
+		"well-known types": {
+			a: known(),
+			b: func() proto.Message {
+				k := known()
+				k.StructField.Fields["a"] = structpb.NewNumberValue(2)
+				k.TimestampField.Nanos = 1
+				return k
+			}(),
+			paths: []string{"struct_field", "timestamp_field"},
+			mask:  []string{"struct_field", "timestamp_field"},
+		},
+		"well-known type root": {
+			a:     &timestamppb.Timestamp{Seconds: 1},
+			b:     &timestamppb.Timestamp{Seconds: 2},
+			paths: []string{""},
+		},
+		"field names as keys": {
+			options: []Option{FieldNamesAsKeys()},
+			a:       person(),
+			b:       func() proto.Message { p := person(); p.Children[0].Id = 7; p.Id = 0; return p }(),
+			paths:   []string{"id", "children[0].id"},
+			mask:    []string{"children", "id"},
+		},
+		"hmac": {
+			options: []Option{HMAC("k", []byte("key"), KeyAllNodes)},
+			a:       person(),
+			b:       func() proto.Message { p := person(); p.StructuredName.First = "Augusta"; return p }(),
+			paths:   []string{"structured_name.first"},
+			mask:    []string{"structured_name.first"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			h := NewHasher(tc.options...).(DiffingProtoHasher)
+			got, err := h.Diff(tc.a.ProtoReflect(), tc.b.ProtoReflect())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.paths, got.Paths); diff != "" {
+				t.Errorf("paths (-want +got):\n%s", diff)
+			}
+			want := &fieldmaskpb.FieldMask{Paths: tc.mask}
+			if diff := cmp.Diff(want, got.FieldMask, protocmp.Transform()); diff != "" {
+				t.Errorf("field mask (-want +got):\n%s", diff)
+			}
+			if !got.FieldMask.IsValid(tc.a) {
+				t.Errorf("invalid field mask %v", got.FieldMask.GetPaths())
+			}
+
+			treeA, err := h.(TreeProtoHasher).HashTree(tc.a.ProtoReflect())
+			if err != nil {
+				t.Fatal(err)
+			}
+			treeB, err := h.(TreeProtoHasher).HashTree(tc.b.ProtoReflect())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, DiffTrees(treeA, treeB), protocmp.Transform()); diff != "" {
+				t.Errorf("DiffTrees (-Diff +DiffTrees):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestDiffErrors(t *testing.T) {
+	h := NewHasher().(DiffingProtoHasher)
+	if _, err := h.Diff(nil, (&pb3_latest.Simple{}).ProtoReflect()); err == nil {
+		t.Error("nil message: expected an error")
+	}
+	if _, err := h.Diff((&pb3_latest.Simple{}).ProtoReflect(), (&pb3_latest.PersonV4{}).ProtoReflect()); err == nil {
+		t.Error("different types: expected an error")
+	}
+}
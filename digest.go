@@ -0,0 +1,48 @@
+package protoreflecthash
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+
+	"golang.org/x/crypto/sha3"
+	"lukechampine.com/blake3"
+)
+
+// HashFunc is an option that sets the hash function used to compute the hash
+// of every node (primitive values, lists, maps and messages).  The default is
+// SHA-256.
+func HashFunc(newHash func() hash.Hash) Option {
+	return func(h *hasher) {
+		h.digest.newHash = newHash
+	}
+}
+
+// SHA256 is an option that hashes with SHA-256.  This is the default.
+func SHA256() Option {
+	return HashFunc(sha256.New)
+}
+
+// SHA384 is an option that hashes with SHA-384.
+func SHA384() Option {
+	return HashFunc(sha512.New384)
+}
+
+// SHA512_256 is an option that hashes with SHA-512/256.
+func SHA512_256() Option {
+	return HashFunc(sha512.New512_256)
+}
+
+// SHA3_256 is an option that hashes with SHA3-256.
+func SHA3_256() Option {
+	return HashFunc(sha3.New256)
+}
+
+// BLAKE3 is an option that hashes with BLAKE3, having a 256-bit output.  The
+// implementation is lukechampine.com/blake3, which is not pure Go: on amd64 it
+// uses assembly, selected at run time with github.com/klauspost/cpuid.
+func BLAKE3() Option {
+	return HashFunc(func() hash.Hash {
+		return blake3.New(32, nil)
+	})
+}
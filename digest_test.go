@@ -0,0 +1,88 @@
+package protoreflecthash
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/crypto/sha3"
+	"lukechampine.com/blake3"
+
+	pb3_latest "github.com/stackb/protoreflecthash/test_protos/generated/latest/proto3"
+)
+
+func TestHashFunc(t *testing.T) {
+	msg := &pb3_latest.Simple{
+		StringField: "foo",
+		DoubleField: 1.5,
+		Int32Field:  -1,
+	}
+
+	for name, tc := range map[string]struct {
+		option  Option
+		newHash func() hash.Hash
+		want    string
+	}{
+		"SHA-256": {
+			option:  SHA256(),
+			newHash: sha256.New,
+			want:    "fa7d0585255667ac4dcdb975ea8343a9f7a9cf56f37146d97a22124f074ba1ff",
+		},
+		"SHA-384": {
+			option:  SHA384(),
+			newHash: sha512.New384,
+			want:    "e46c540889a7c4224e3ece4bb306add2cf1d3e31b0feb028a724749e5c23cb0ce8a9ffb4b9835351dddd52321edca24d",
+		},
+		"SHA-512/256": {
+			option:  SHA512_256(),
+			newHash: sha512.New512_256,
+			want:    "bec260c2d6ebcbe15b5e95391f9140ba29e86b58b5c57fcb8ff2ab9ecf48972f",
+		},
+		"SHA3-256": {
+			option:  SHA3_256(),
+			newHash: sha3.New256,
+			want:    "dfcf9216b15e2db86e70b799ffb142b07f037e9389393f2d60f99f3c5229b928",
+		},
+		"BLAKE3": {
+			option:  BLAKE3(),
+			newHash: func() hash.Hash { return blake3.New(32, nil) },
+			want:    "98c5a79f8f9f3f9850c78213038acd44d45f09e87b8696846cad149b4a40e283",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			h := NewHasher(tc.option).(*hasher)
+
+			// Primitives are hashed with the selected digest.
+			d := tc.newHash()
+			d.Write([]byte("b1"))
+			if diff := cmp.Diff(fmt.Sprintf("%x", d.Sum(nil)), getHash(t, func() ([]byte, error) {
+				return h.hashBool(true)
+			})); diff != "" {
+				t.Errorf("bool (-want +got):\n%s", diff)
+			}
+
+			// ...as are composites.
+			got := getHash(t, func() ([]byte, error) {
+				return h.HashProto(msg.ProtoReflect())
+			})
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("protohash (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	t.Run("default", func(t *testing.T) {
+		want := getHash(t, func() ([]byte, error) {
+			return NewHasher(SHA256()).HashProto(msg.ProtoReflect())
+		})
+		got := getHash(t, func() ([]byte, error) {
+			return NewHasher().HashProto(msg.ProtoReflect())
+		})
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("protohash (-want +got):\n%s", diff)
+		}
+	})
+}
@@ -0,0 +1,114 @@
+package protoreflecthash
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ExplainingProtoHasher is implemented by the ProtoHasher returned by NewHasher.
+type ExplainingProtoHasher interface {
+	ProtoHasher
+	// Explain returns a human-readable report of how the hash of msg is
+	// computed: for every node, indented below its parent, its type
+	// identifier, the pre-image hashed along with the identifier and the
+	// resulting hash.  This is meant for finding where two implementations of
+	// the hashing scheme diverge.
+	Explain(msg protoreflect.Message) (string, error)
+}
+
+// Explain implements ExplainingProtoHasher.
+func (h *hasher) Explain(msg protoreflect.Message) (string, error) {
+	root, err := h.HashTree(msg)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := WriteExplanation(&buf, string(msg.Descriptor().FullName()), root); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// WriteExplanation writes the report of Explain for the given hash tree, whose
+// root is labeled name.
+func WriteExplanation(w io.Writer, name string, root *HashNode) error {
+	return writeExplanation(w, name, root, 0)
+}
+
+func writeExplanation(w io.Writer, label string, node *HashNode, depth int) error {
+	indent := strings.Repeat("  ", depth)
+	pre, err := node.PreImage()
+	if err != nil {
+		return fmt.Errorf("%s: %w", label, err)
+	}
+
+	lines := []string{fmt.Sprintf("%s%s: %s", indent, label, node.Identifier)}
+	if node.KeyHash != nil {
+		lines = append(lines, fmt.Sprintf("%s  key hash: %s", indent, node.KeyHash))
+	}
+	lines = append(lines,
+		fmt.Sprintf("%s  pre-image: %s", indent, formatPreImage(node.Identifier, pre)),
+		fmt.Sprintf("%s  hash: %s", indent, node.Hash),
+	)
+	if _, err := io.WriteString(w, strings.Join(lines, "\n")+"\n"); err != nil {
+		return err
+	}
+
+	for i, child := range node.Children {
+		if err := writeExplanation(w, childLabel(i, child), child, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// childLabel returns the label of the i-th child of a node: its path, or for
+// nodes within well-known types, its key or index.
+func childLabel(i int, child *HashNode) string {
+	switch {
+	case child.Path != "":
+		return child.Path
+	case child.KeyHash != nil:
+		return "key " + strconv.Quote(child.Key)
+	default:
+		return "[" + strconv.Itoa(i) + "]"
+	}
+}
+
+// formatPreImage returns the pre-image of a node in readable form: quoted for
+// leaves other than bytes, and hex-encoded otherwise.
+func formatPreImage(t string, pre []byte) string {
+	if t != byteIdentifier && isLeafIdentifier(t) {
+		return strconv.Quote(string(pre))
+	}
+	return fmt.Sprintf("%d bytes %s", len(pre), hex.EncodeToString(pre))
+}
+
+// PreImage returns the bytes hashed along with the identifier of the node to
+// compute its hash: the canonical form of a leaf, the hashes of the elements or
+// members of a list or set, or the key hashes and hashes of the entries of a
+// message or map.
+func (n *HashNode) PreImage() ([]byte, error) {
+	switch {
+	case n.Identifier == byteIdentifier:
+		return hex.DecodeString(n.Value)
+	case isLeafIdentifier(n.Identifier):
+		return []byte(n.Value), nil
+	}
+
+	var pre []byte
+	for _, child := range n.Children {
+		if n.Identifier != listIdentifier && n.Identifier != setIdentifier {
+			pre = append(pre, child.KeyHash...)
+		}
+		pre = append(pre, child.Hash...)
+	}
+	return pre, nil
+}
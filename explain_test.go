@@ -0,0 +1,142 @@
+package protoreflecthash
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	pb3_latest "github.com/stackb/protoreflecthash/test_protos/generated/latest/proto3"
+)
+
+func TestExplain(t *testing.T) {
+	var d digest
+	sum := func(t string, parts ...[]byte) []byte {
+		hash, err := d.hash(t, bytes.Join(parts, nil))
+		if err != nil {
+			panic(err)
+		}
+		return hash
+	}
+	x := hex.EncodeToString
+
+	k3 := sum("i", []byte("3"))
+	v3 := sum("r", []byte{0x01, 0xff})
+	k5 := sum("i", []byte("5"))
+	v5 := sum("f", []byte("+-1:1"))
+	k33 := sum("i", []byte("33"))
+	k13 := sum("i", []byte("13"))
+	e0 := sum("i", []byte("1"))
+	e1 := sum("i", []byte("-2"))
+	list := sum("l", e0, e1)
+	repetitive := sum("d", k13, list)
+	root := sum("d", k3, v3, k5, v5, k33, repetitive)
+
+	preImage := func(parts ...[]byte) string {
+		pre := bytes.Join(parts, nil)
+		return fmt.Sprintf("%d bytes %x", len(pre), pre)
+	}
+	want := strings.Join([]string{
+		"schema.proto3.Simple: d",
+		"  pre-image: " + preImage(k3, v3, k5, v5, k33, repetitive),
+		"  hash: " + x(root),
+		"  bytes_field: r",
+		"    key hash: " + x(k3),
+		"    pre-image: 2 bytes 01ff",
+		"    hash: " + x(v3),
+		"  double_field: f",
+		"    key hash: " + x(k5),
+		`    pre-image: "+-1:1"`,
+		"    hash: " + x(v5),
+		"  repetitive_field: d",
+		"    key hash: " + x(k33),
+		"    pre-image: " + preImage(k13, list),
+		"    hash: " + x(repetitive),
+		"    repetitive_field.int32_field: l",
+		"      key hash: " + x(k13),
+		"      pre-image: " + preImage(e0, e1),
+		"      hash: " + x(list),
+		"      repetitive_field.int32_field[0]: i",
+		`        pre-image: "1"`,
+		"        hash: " + x(e0),
+		"      repetitive_field.int32_field[1]: i",
+		`        pre-image: "-2"`,
+		"        hash: " + x(e1),
+		"",
+	}, "\n")
+
+	msg := &pb3_latest.Simple{
+		BytesField:      []byte{0x01, 0xff},
+		DoubleField:     0.5,
+		RepetitiveField: &pb3_latest.Repetitive{Int32Field: []int32{1, -2}},
+	}
+	h := NewHasher().(ExplainingProtoHasher)
+	got, err := h.Explain(msg.ProtoReflect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want +got):\n%s", diff)
+	}
+}
+
+func TestHashNodePreImage(t *testing.T) {
+	st, err := structpb.NewStruct(map[string]interface{}{
+		"a": 1.5,
+		"b": []interface{}{"x", "x", true, nil},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, tc := range map[string]struct {
+		options []Option
+		msg     proto.Message
+	}{
+		"struct": {
+			msg: st,
+		},
+		"messages and lists": {
+			msg: &pb3_latest.PersonV4{Id: 1, Children: []*pb3_latest.PersonV3{{Id: 2}, {Id: 2}}},
+		},
+		"maps": {
+			msg: &pb3_latest.StringMaps{StringToBytes: map[string][]byte{"a": {0x00}, "b": nil}},
+		},
+		"hmac": {
+			options: []Option{HMAC("k", []byte("key"), KeyAllNodes)},
+			msg:     st,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			hasher := NewHasher(tc.options...).(*hasher)
+			root, err := hasher.HashTree(tc.msg.ProtoReflect())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var check func(node *HashNode)
+			check = func(node *HashNode) {
+				pre, err := node.PreImage()
+				if err != nil {
+					t.Fatal(err)
+				}
+				hash, err := hasher.digest.hash(node.Identifier, pre)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(hash, node.Hash) {
+					t.Errorf("node %q (%s): want hash %x, got %x", node.Path, node.Identifier, hash, node.Hash)
+				}
+				for _, child := range node.Children {
+					check(child)
+				}
+			}
+			check(root)
+		})
+	}
+}
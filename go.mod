@@ -4,8 +4,20 @@ go 1.17
 
 require (
 	github.com/benlaurie/objecthash v0.0.0-20180202135721-d1e3d6079fc1
-	github.com/google/go-cmp v0.5.5
+	github.com/google/go-cmp v0.5.9
+	golang.org/x/crypto v0.10.0
+	google.golang.org/grpc v1.55.0
 	google.golang.org/protobuf v1.30.0
+	lukechampine.com/blake3 v1.1.7
+)
+
+require (
+	github.com/golang/protobuf v1.5.3 // indirect
+	github.com/klauspost/cpuid/v2 v2.0.9 // indirect
+	golang.org/x/net v0.10.0 // indirect
+	golang.org/x/sys v0.9.0 // indirect
+	golang.org/x/text v0.10.0 // indirect
+	google.golang.org/genproto v0.0.0-20230306155012-7f2fa6fef1f4 // indirect
 )
 
 replace github.com/benlaurie/objecthash => github.com/pcj/objecthash v0.0.0-20230619225455-9b9e2d0ef194
@@ -2,15 +2,66 @@ package protoreflecthash
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"sort"
+	"strings"
+	"sync"
 
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
 )
 
 const valueName = protoreflect.Name("value")
 
+// ErrUnresolvedAny is returned when the type URL of a google.protobuf.Any
+// cannot be resolved and the UnresolvedAnyFail policy is in effect.
+var ErrUnresolvedAny = errors.New("unresolvable google.protobuf.Any type")
+
+// ErrExtensionsNotAllowed is returned when a message has populated extension
+// fields and the RejectExtensions option is in effect.
+var ErrExtensionsNotAllowed = errors.New("extension fields not allowed")
+
+// ErrUnknownFields is returned when a message has unknown fields and the
+// UnknownFieldsReject mode is in effect.
+var ErrUnknownFields = errors.New("unknown fields not allowed")
+
+// UnknownFieldsMode determines how the unknown fields of a message (those
+// present on the wire but not in the message descriptor) are hashed.
+type UnknownFieldsMode int
+
+const (
+	// UnknownFieldsIgnore excludes unknown fields from the hash.  This is the
+	// default.
+	UnknownFieldsIgnore UnknownFieldsMode = iota
+	// UnknownFieldsReject fails hashing with ErrUnknownFields, listing the
+	// unknown field numbers.
+	UnknownFieldsReject
+	// UnknownFieldsInclude hashes unknown fields from their wire
+	// representation, keyed by field number.  Well-known types carrying
+	// unknown fields are then hashed as regular messages, rather than by
+	// their canonical hash.
+	UnknownFieldsInclude
+)
+
+// UnresolvedAnyPolicy determines how a google.protobuf.Any is hashed when its
+// type URL cannot be resolved to a message type.
+type UnresolvedAnyPolicy int
+
+const (
+	// UnresolvedAnyFail fails hashing with ErrUnresolvedAny.  This is the
+	// default.
+	UnresolvedAnyFail UnresolvedAnyPolicy = iota
+	// UnresolvedAnyOpaque hashes the value field as opaque bytes, together with
+	// the type URL.  The hash is therefore sensitive to the serialization of the
+	// packed message.
+	UnresolvedAnyOpaque
+	// UnresolvedAnyWire hashes the value field as descriptor-less wire data,
+	// keyed by field number, together with the type URL.
+	UnresolvedAnyWire
+)
+
 // Option modifies how hashes for protobufs is calculated.
 type Option func(*hasher)
 
@@ -28,6 +79,8 @@ func NewHasher(options ...Option) ProtoHasher {
 	for _, opt := range options {
 		opt(h)
 	}
+	h.digest.pool()
+	h.plans = &sync.Map{}
 	return h
 }
 
@@ -40,13 +93,48 @@ func MessageFullnameIdentifier() Option {
 }
 
 // FieldNamesAsKeys is an option that uses field names for key hashing rather
-// than the field number.
+// than the field number.  Extension fields are keyed by their fully-qualified
+// name.
 func FieldNamesAsKeys() Option {
 	return func(h *hasher) {
 		h.fieldNamesAsKeys = true
 	}
 }
 
+// TypeResolver is an option that sets the resolver used to look up the message
+// type packed in a google.protobuf.Any, and the message type of placeholder
+// descriptors.  If not set, protoregistry.GlobalTypes is used.  See
+// FilesTypeResolver to resolve types from a set of file descriptors.
+func TypeResolver(resolver protoregistry.MessageTypeResolver) Option {
+	return func(h *hasher) {
+		h.typeResolver = resolver
+	}
+}
+
+// UnresolvedAny is an option that sets the policy for hashing
+// google.protobuf.Any messages whose type URL cannot be resolved.
+func UnresolvedAny(policy UnresolvedAnyPolicy) Option {
+	return func(h *hasher) {
+		h.unresolvedAnyPolicy = policy
+	}
+}
+
+// RejectExtensions is an option that fails hashing with
+// ErrExtensionsNotAllowed for messages having populated extension fields,
+// rather than including them in the hash.
+func RejectExtensions() Option {
+	return func(h *hasher) {
+		h.rejectExtensions = true
+	}
+}
+
+// UnknownFields is an option that sets the mode for hashing unknown fields.
+func UnknownFields(mode UnknownFieldsMode) Option {
+	return func(h *hasher) {
+		h.unknownFieldsMode = mode
+	}
+}
+
 type hasher struct {
 	// Whether to use the proto field name as its key, as opposed to using the
 	// tag number as the key.
@@ -54,85 +142,258 @@ type hasher struct {
 	// Whether to use the fullname of the message descriptor rather than 'm'
 	// (mapIdentifier) for proto messages.
 	messageFullnameIdentifier bool
+	// The resolver used to unpack google.protobuf.Any messages and resolve
+	// placeholder descriptors.  If nil, protoregistry.GlobalTypes is used.
+	typeResolver protoregistry.MessageTypeResolver
+	// How to hash google.protobuf.Any messages having an unresolvable type URL.
+	unresolvedAnyPolicy UnresolvedAnyPolicy
+	// Whether to fail on messages having populated extension fields.
+	rejectExtensions bool
+	// How to hash unknown fields.
+	unknownFieldsMode UnknownFieldsMode
+	// How to validate messages before hashing them.
+	validation ValidationMode
+	// The digest used to hash each node.
+	digest digest
+	// The HMAC key, its identifier and the nodes it applies to.  If the scope
+	// is KeyAllNodes, the digest holds the key as well.
+	keyID    string
+	key      []byte
+	keyScope KeyScope
+	// The key of the root node, while hashing the root with the scope KeyRoot.
+	// It is cleared for the children of the root.
+	rootKey []byte
+	// The visitor notified of the values hashed, for operations acting on
+	// particular paths within the message.  If nil, paths are not tracked.
+	visitor visitor
+	// The plans for hashing the message types hashed so far, by descriptor.  If
+	// nil, plans are derived for every message.
+	plans *sync.Map
+	// The bounds on hashing lists and maps concurrently.  If nil, they are
+	// hashed sequentially.
+	parallelism *parallelism
 }
 
 type fieldHashEntry struct {
 	number int32
 	khash  []byte
 	vhash  []byte
+	// unknown reports whether the entry holds the unknown fields of its number,
+	// which may also be the number of a known field sent with another wire
+	// type.
+	unknown bool
+}
+
+// fieldHashEntryLess reports whether entry a precedes entry b in the hash of
+// their message: entries are ordered by field number, and the entry of a known
+// field precedes the one of the unknown fields having the same number.
+func fieldHashEntryLess(a, b *fieldHashEntry) bool {
+	if a.number != b.number {
+		return a.number < b.number
+	}
+	return !a.unknown && b.unknown
+}
+
+// AppendingProtoHasher is implemented by the ProtoHasher returned by NewHasher.
+type AppendingProtoHasher interface {
+	ProtoHasher
+	// AppendHash appends the object hash of msg to dst and returns the extended
+	// buffer.  The hash is the one returned by HashProto, but hashing into a
+	// buffer having enough capacity for it allocates less.
+	AppendHash(dst []byte, msg protoreflect.Message) ([]byte, error)
 }
 
 // HashProto implements MessageHasher
 func (h *hasher) HashProto(msg protoreflect.Message) ([]byte, error) {
+	return h.hashRoot(msg, (*hasher).hashProto)
+}
+
+// AppendHash implements AppendingProtoHasher.
+func (h *hasher) AppendHash(dst []byte, msg protoreflect.Message) ([]byte, error) {
+	if h.key != nil && h.keyScope == KeyRoot {
+		hash, err := h.HashProto(msg)
+		if err != nil {
+			return nil, err
+		}
+		return append(dst, hash...), nil
+	}
+	return h.appendProto(dst, msg)
+}
+
+// hashRoot computes the hash of the root message with the given function,
+// keying the root node if the hasher is keyed at the root only.
+func (h *hasher) hashRoot(msg protoreflect.Message, hashFunc func(*hasher, protoreflect.Message) ([]byte, error)) ([]byte, error) {
+	if h.key != nil && h.keyScope == KeyRoot {
+		rh := *h
+		rh.rootKey = h.key
+		return hashFunc(&rh, msg)
+	}
+	return hashFunc(h, msg)
+}
+
+func (h *hasher) hashProto(msg protoreflect.Message) ([]byte, error) {
+	return h.appendProto(nil, msg)
+}
+
+func (h *hasher) appendProto(dst []byte, msg protoreflect.Message) ([]byte, error) {
 	// Check if the value is nil.
 	if msg == nil {
-		return h.hashNil()
+		node, _ := h.splitRoot()
+		return node.digest.appendNil(dst), nil
 	}
 
-	// Make sure the proto itself is actually valid (ie. can be marshalled).
-	// If this fails, it probably means there are unset required fields or invalid
-	// values.
-	if _, err := proto.Marshal(msg.Interface()); err != nil {
+	if err := h.validate(msg); err != nil {
 		return nil, err
 	}
 
-	return h.hashMessage(msg)
+	return h.appendMessage(dst, msg)
 }
 
 func (h *hasher) hashMessage(msg protoreflect.Message) ([]byte, error) {
+	return h.appendMessage(nil, msg)
+}
+
+func (h *hasher) appendMessage(dst []byte, msg protoreflect.Message) ([]byte, error) {
 	if msg == nil {
-		return hashNil()
+		node, _ := h.splitRoot()
+		return node.digest.appendNil(dst), nil
 	}
 
 	md := msg.Descriptor()
 
-	if hash, err, ok := h.hashWellKnownType(md, msg); ok {
-		return hash, err
+	// Placeholders must be resolved before checking for well-known types, as
+	// a placeholder has no fields.
+	if md.IsPlaceholder() {
+		resolved, err := h.resolvePlaceholder(msg)
+		if err != nil {
+			return nil, err
+		}
+		msg = resolved
+		md = msg.Descriptor()
 	}
 
-	// TOOD(pcj): what is the correct handling of placeholder types?
-	if md.IsPlaceholder() {
-		return nil, nil
+	plan := h.plan(md)
+	if plan.wellKnown != nil {
+		hash, err := h.hashWellKnownRoot(plan.wellKnown, md, msg)
+		if err != nil {
+			return nil, err
+		}
+		return append(dst, hash...), nil
+	}
+
+	node, h := h.splitRoot()
+	if h.canStreamFields(plan, msg) {
+		return h.appendFields(dst, node, plan, msg)
 	}
 
 	var hashes []*fieldHashEntry
 
-	fieldHashes, err := h.hashFields(msg, md.Fields())
+	fieldHashes, err := h.hashFields(msg, plan.fields)
 	if err != nil {
 		return nil, fmt.Errorf("hashing fields: %w", err)
 	}
 	hashes = append(hashes, fieldHashes...)
 
-	sort.Slice(hashes, func(i, j int) bool {
-		return hashes[i].number < hashes[j].number
-	})
+	extensionHashes, err := h.hashExtensions(msg)
+	if err != nil {
+		return nil, fmt.Errorf("hashing extensions: %w", err)
+	}
+	hashes = append(hashes, extensionHashes...)
 
-	var buf bytes.Buffer
-	for _, hash := range hashes {
-		buf.Write(hash.khash)
-		buf.Write(hash.vhash)
+	unknownHashes, err := h.hashUnknownFields(msg)
+	if err != nil {
+		return nil, fmt.Errorf("hashing unknown fields: %w", err)
 	}
+	hashes = append(hashes, unknownHashes...)
 
-	identifier := mapIdentifier
-	if h.messageFullnameIdentifier {
-		identifier = string(md.FullName())
+	if h.visitor != nil {
+		absentHashes, err := h.visitor.absentFields(h, msg)
+		if err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, absentHashes...)
 	}
 
-	return hash(identifier, buf.Bytes())
+	return node.appendFieldHashEntries(dst, md, hashes), nil
+}
+
+// canStreamFields reports whether the hashes of the fields of msg can be
+// written to its hash as they are computed, in the order of the planned fields:
+// when they are all regular fields.
+func (h *hasher) canStreamFields(plan *messagePlan, msg protoreflect.Message) bool {
+	if h.visitor != nil || plan.extendable {
+		return false
+	}
+	return h.unknownFieldsMode == UnknownFieldsIgnore || len(msg.GetUnknown()) == 0
 }
 
-func (h *hasher) hashFields(msg protoreflect.Message, fields protoreflect.FieldDescriptors) ([]*fieldHashEntry, error) {
-	hashes := make([]*fieldHashEntry, 0, fields.Len())
+// appendFields appends to dst the hash of msg, writing the hashes of its
+// fields to it as they are computed.  The node of msg is hashed by node and
+// its fields by h.  See canStreamFields.
+func (h *hasher) appendFields(dst []byte, node *hasher, plan *messagePlan, msg protoreflect.Message) ([]byte, error) {
+	w := node.digest.newNode(plan.identifier)
 
-	for i := 0; i < fields.Len(); i++ {
-		fd := fields.Get(i)
+	for i := range plan.fields {
+		fp := &plan.fields[i]
+		fd := fp.fd
 		if !msg.Has(fd) {
+			continue
+		}
+		w.writeChild(h.fieldKey(w.child(), fp))
+
+		vhash, err := fp.appendValue(h, w.child(), fd, msg.Get(fd))
+		if err != nil {
+			w.release()
+			return nil, fmt.Errorf("hashing fields: hashing field value %d (%s): %w", fd.Number(), fd.FullName(), err)
+		}
+		w.writeChild(vhash)
+	}
+
+	return w.appendSum(dst), nil
+}
+
+// messageIdentifier returns the type identifier of messages of the given type,
+// which may be nil for messages hashed from raw wire data.
+func (h *hasher) messageIdentifier(md protoreflect.MessageDescriptor) string {
+	if h.messageFullnameIdentifier && md != nil {
+		return string(md.FullName())
+	}
+	return mapIdentifier
+}
+
+// hashFieldHashEntries computes the hash of a message from the hashes of its
+// fields, ordered by fieldHashEntryLess.  The descriptor may be nil for messages
+// hashed from raw wire data.
+func (h *hasher) hashFieldHashEntries(md protoreflect.MessageDescriptor, hashes []*fieldHashEntry) ([]byte, error) {
+	return h.appendFieldHashEntries(nil, md, hashes), nil
+}
+
+func (h *hasher) appendFieldHashEntries(dst []byte, md protoreflect.MessageDescriptor, hashes []*fieldHashEntry) []byte {
+	sort.SliceStable(hashes, func(i, j int) bool {
+		return fieldHashEntryLess(hashes[i], hashes[j])
+	})
+
+	w := h.digest.newNode(h.messageIdentifier(md))
+	for _, hash := range hashes {
+		w.write(hash.khash)
+		w.write(hash.vhash)
+	}
+
+	return w.appendSum(dst)
+}
+
+func (h *hasher) hashFields(msg protoreflect.Message, fields []fieldPlan) ([]*fieldHashEntry, error) {
+	hashes := make([]*fieldHashEntry, 0, len(fields))
+
+	for i := range fields {
+		fp := &fields[i]
+		if !msg.Has(fp.fd) {
 			// if we are in this block and the field is a scalar one, it is
 			// either a proto3 field that was never set or is the empty value
 			// (indistinguishable) or this is a proto2 field that is nil.
 			continue
 		}
-		hash, err := h.hashField(fd, msg.Get(fd))
+		hash, err := h.hashFieldValueWithKey(fp.fd, h.fieldKey(nil, fp), msg.Get(fp.fd), fp.appendValue)
 		if err != nil {
 			return nil, err
 		}
@@ -142,13 +403,48 @@ func (h *hasher) hashFields(msg protoreflect.Message, fields protoreflect.FieldD
 	return hashes, nil
 }
 
+// hashExtensions hashes the populated extension fields of the message.
+func (h *hasher) hashExtensions(msg protoreflect.Message) ([]*fieldHashEntry, error) {
+	var hashes []*fieldHashEntry
+	var err error
+
+	msg.Range(func(fd protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		if !fd.IsExtension() {
+			return true
+		}
+		if h.rejectExtensions {
+			err = fmt.Errorf("%w: %s", ErrExtensionsNotAllowed, fd.FullName())
+			return false
+		}
+		var hash *fieldHashEntry
+		hash, err = h.hashField(fd, value)
+		if err != nil {
+			return false
+		}
+		hashes = append(hashes, hash)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return hashes, nil
+}
+
 func (h *hasher) hashField(fd protoreflect.FieldDescriptor, value protoreflect.Value) (*fieldHashEntry, error) {
 	khash, err := h.hashFieldKey(fd)
 	if err != nil {
 		return nil, fmt.Errorf("hashing field key %d (%s): %w", fd.Number(), fd.FullName(), err)
 	}
+	return h.hashFieldValueWithKey(fd, khash, value, (*hasher).appendFieldValue)
+}
 
-	vhash, err := h.hashFieldValue(fd, value)
+// hashFieldValueWithKey returns the hash entry of a field, given the hash of
+// its key and the function appending the hash of its value.
+func (h *hasher) hashFieldValueWithKey(fd protoreflect.FieldDescriptor, khash []byte, value protoreflect.Value, appendValue func(*hasher, []byte, protoreflect.FieldDescriptor, protoreflect.Value) ([]byte, error)) (*fieldHashEntry, error) {
+	vhash, err := h.appendStep(nil, func() PathStep { return fieldStep(fd) }, khash, func(dst []byte) ([]byte, error) {
+		return appendValue(h, dst, fd, value)
+	})
 	if err != nil {
 		return nil, fmt.Errorf("hashing field value %d (%s): %w", fd.Number(), fd.FullName(), err)
 	}
@@ -161,36 +457,51 @@ func (h *hasher) hashField(fd protoreflect.FieldDescriptor, value protoreflect.V
 }
 
 func (h *hasher) hashFieldKey(fd protoreflect.FieldDescriptor) ([]byte, error) {
+	return h.appendFieldKey(nil, fd), nil
+}
+
+func (h *hasher) appendFieldKey(dst []byte, fd protoreflect.FieldDescriptor) []byte {
 	if h.fieldNamesAsKeys {
-		return hashUnicode(string(fd.Name()))
+		if fd.IsExtension() {
+			return h.digest.appendUnicode(dst, string(fd.FullName()))
+		}
+		return h.digest.appendUnicode(dst, string(fd.Name()))
 	}
-	return hashInt64(int64(fd.Number()))
+	return h.digest.appendInt64(dst, int64(fd.Number()))
 }
 
 func (h *hasher) hashFieldValue(fd protoreflect.FieldDescriptor, value protoreflect.Value) ([]byte, error) {
+	return h.appendFieldValue(nil, fd, value)
+}
+
+func (h *hasher) appendFieldValue(dst []byte, fd protoreflect.FieldDescriptor, value protoreflect.Value) ([]byte, error) {
 	if fd.IsList() {
-		return h.hashList(fd.Kind(), value.List())
+		return h.appendList(dst, fd.Kind(), value.List())
 	}
 	if fd.IsMap() {
-		return h.hashMap(fd.MapKey(), fd.MapValue(), value.Map())
+		return h.appendMap(dst, fd.MapKey(), fd.MapValue(), value.Map())
 	}
-	return h.hashValue(fd.Kind(), value)
+	return h.appendValue(dst, fd.Kind(), value)
 }
 
 func (h *hasher) hashValue(kind protoreflect.Kind, value protoreflect.Value) ([]byte, error) {
+	return h.appendValue(nil, kind, value)
+}
+
+func (h *hasher) appendValue(dst []byte, kind protoreflect.Kind, value protoreflect.Value) ([]byte, error) {
 	switch kind {
 	case
 		protoreflect.BoolKind:
-		return h.hashBool(value.Bool())
+		return h.digest.appendBool(dst, value.Bool()), nil
 	case
 		protoreflect.EnumKind:
-		return h.hashEnum(value.Enum())
+		return h.digest.appendInt64(dst, int64(value.Enum())), nil
 	case
 		protoreflect.Uint32Kind,
 		protoreflect.Uint64Kind,
 		protoreflect.Fixed32Kind,
 		protoreflect.Fixed64Kind:
-		return h.hashUint(value.Uint())
+		return h.digest.appendUint64(dst, value.Uint()), nil
 	case
 		protoreflect.Int32Kind,
 		protoreflect.Int64Kind,
@@ -198,161 +509,353 @@ func (h *hasher) hashValue(kind protoreflect.Kind, value protoreflect.Value) ([]
 		protoreflect.Sint64Kind,
 		protoreflect.Sfixed32Kind,
 		protoreflect.Sfixed64Kind:
-		return h.hashInt(value.Int())
+		return h.digest.appendInt64(dst, value.Int()), nil
 	case
 		protoreflect.FloatKind,
 		protoreflect.DoubleKind:
-		return h.hashFloat(value.Float())
+		return h.digest.appendFloat(dst, value.Float())
 	case
 		protoreflect.StringKind:
-		return h.hashString(value.String())
+		return h.digest.appendUnicode(dst, value.String()), nil
 	case
 		protoreflect.BytesKind:
-		return h.hashBytes(value.Bytes())
-	case
-		protoreflect.MessageKind:
-		return h.hashMessage(value.Message())
+		return h.digest.appendBytes(dst, value.Bytes()), nil
 	case
+		protoreflect.MessageKind,
 		protoreflect.GroupKind:
-		return nil, fmt.Errorf("protoreflect.GroupKind: not implemented: %T", value)
+		// Groups (and delimited-encoded messages) differ from other messages only
+		// in their wire encoding.
+		return h.appendMessage(dst, value.Message())
 	}
 	return nil, fmt.Errorf("unexpected field kind: %v (%T)", kind, value)
 }
 
 func (h *hasher) hashNil() ([]byte, error) {
-	return hashNil()
+	return h.digest.hashNil()
 }
 
 func (h *hasher) hashBool(value bool) ([]byte, error) {
-	return hashBool(value)
+	return h.digest.hashBool(value)
 }
 
 func (h *hasher) hashEnum(value protoreflect.EnumNumber) ([]byte, error) {
-	return hashInt64(int64(value))
+	return h.digest.hashInt64(int64(value))
 }
 
 func (h *hasher) hashInt(value int64) ([]byte, error) {
-	return hashInt64(value)
+	return h.digest.hashInt64(value)
 }
 
 func (h *hasher) hashUint(value uint64) ([]byte, error) {
-	return hashUint64(value)
+	return h.digest.hashUint64(value)
 }
 
 func (h *hasher) hashFloat(value float64) ([]byte, error) {
-	return hashFloat(value)
+	return h.digest.hashFloat(value)
 }
 
 func (h *hasher) hashString(value string) ([]byte, error) {
-	return hashUnicode(value)
+	return h.digest.hashUnicode(value)
 }
 
 func (h *hasher) hashBytes(value []byte) ([]byte, error) {
-	return hashBytes(value)
+	return h.digest.hashBytes(value)
 }
 
 func (h *hasher) hashList(kind protoreflect.Kind, list protoreflect.List) ([]byte, error) {
-	var buf bytes.Buffer
+	return h.appendList(nil, kind, list)
+}
+
+func (h *hasher) appendList(dst []byte, kind protoreflect.Kind, list protoreflect.List) ([]byte, error) {
+	if h.isParallel(list.Len()) {
+		return h.appendListParallel(dst, kind, list)
+	}
+
+	w := h.digest.newNode(listIdentifier)
 
 	for i := 0; i < list.Len(); i++ {
 		value := list.Get(i)
-		data, err := h.hashValue(kind, value)
+		data, err := h.appendStep(w.child(), func() PathStep { return indexStep(i) }, nil, func(dst []byte) ([]byte, error) {
+			return h.appendValue(dst, kind, value)
+		})
 		if err != nil {
+			w.release()
 			return nil, fmt.Errorf("hashing list item %d: %w", i, err)
 		}
-		buf.Write(data)
+		w.writeChild(data)
 	}
 
-	return hash(listIdentifier, buf.Bytes())
+	return w.appendSum(dst), nil
 }
 
 func (h *hasher) hashMap(kd, fd protoreflect.FieldDescriptor, m protoreflect.Map) ([]byte, error) {
+	return h.appendMap(nil, kd, fd, m)
+}
+
+func (h *hasher) appendMap(dst []byte, kd, fd protoreflect.FieldDescriptor, m protoreflect.Map) ([]byte, error) {
+	if h.isParallel(m.Len()) {
+		return h.appendMapParallel(dst, kd, fd, m)
+	}
 
-	var mapHashEntries []hashMapEntry
+	w := h.digest.newNode(mapIdentifier)
+
+	// The entries are sorted by key hash once all of them are hashed.
+	entries := &w.st.entries
+	entries.buf = entries.buf[:0]
+	entries.size = w.st.hash.Size()
 
 	var errValue error
 	var errKey protoreflect.MapKey
 	m.Range(func(mk protoreflect.MapKey, v protoreflect.Value) bool {
-		khash, err := h.hashFieldValue(kd, mk.Value())
+		var khash []byte
+		var err error
+		if h.visitor == nil {
+			n := len(entries.buf)
+			entries.buf, err = h.appendFieldValue(entries.buf, kd, mk.Value())
+			khash = entries.buf[n:]
+		} else {
+			// The visitor may retain the key hash.
+			khash, err = h.hashFieldValue(kd, mk.Value())
+			entries.buf = append(entries.buf, khash...)
+		}
 		if err != nil {
 			errKey = mk
 			errValue = err
 			return false
 		}
 
-		vhash, err := h.hashFieldValue(fd, v)
+		entries.buf, err = h.appendStep(entries.buf, func() PathStep { return mapKeyStep(mk) }, khash, func(dst []byte) ([]byte, error) {
+			return h.appendFieldValue(dst, fd, v)
+		})
 		if err != nil {
 			errKey = mk
 			errValue = err
 			return false
 		}
 
-		mapHashEntries = append(mapHashEntries, hashMapEntry{
-			khash: khash,
-			vhash: vhash,
-		})
-
 		return true
 	})
 	if errValue != nil {
+		w.release()
 		return nil, fmt.Errorf("hashing map key %v: %w", errKey, errValue)
 	}
 
-	sort.Sort(byKHash(mapHashEntries))
+	sort.Sort(entries)
+	w.write(entries.buf)
+
+	return w.appendSum(dst), nil
+}
+
+// hashWellKnownType computes the hash of the well-known types having a
+// canonical hash that differs from (or must be defined independently of) the
+// hash of a regular message.  Other well-known types, such as
+// google.protobuf.Type, google.protobuf.Api and the descriptor types, are
+// hashed as regular messages.  The hash is computed with hashFunc, as returned
+// by wellKnownTypeHashFunc for the type.
+//
+// Unknown fields are subject to the unknown fields mode like those of any other
+// message.  As the canonical hash has no room for them, a well-known type
+// having unknown fields included in its hash is hashed as a regular message.
+func (h *hasher) hashWellKnownType(hashFunc func(*hasher, protoreflect.MessageDescriptor, protoreflect.Message) ([]byte, error), md protoreflect.MessageDescriptor, msg protoreflect.Message) ([]byte, error) {
+	if h.visitor != nil {
+		// Well-known types are leaves as far as paths are concerned.
+		leaf := *h
+		leaf.visitor = nil
+		h = &leaf
+	}
+
+	unknownHashes, err := h.hashUnknownFields(msg)
+	if err != nil {
+		return nil, fmt.Errorf("hashing unknown fields: %w", err)
+	}
+	if len(unknownHashes) > 0 {
+		return h.hashRegularMessage(md, msg, unknownHashes)
+	}
+
+	return hashFunc(h, md, msg)
+}
+
+// hashRegularMessage hashes msg as a regular message made of its populated
+// fields and the given unknown field hashes, ignoring any canonical hash of its
+// type.
+func (h *hasher) hashRegularMessage(md protoreflect.MessageDescriptor, msg protoreflect.Message, unknownHashes []*fieldHashEntry) ([]byte, error) {
+	hashes := unknownHashes
 
-	var buf bytes.Buffer
-	for _, e := range mapHashEntries {
-		buf.Write(e.khash[:])
-		buf.Write(e.vhash[:])
+	var err error
+	msg.Range(func(fd protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		var hash *fieldHashEntry
+		hash, err = h.hashField(fd, value)
+		if err != nil {
+			return false
+		}
+		hashes = append(hashes, hash)
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("hashing fields: %w", err)
 	}
 
-	return hash(mapIdentifier, buf.Bytes())
+	return h.hashFieldHashEntries(md, hashes)
 }
 
-func (h *hasher) hashWellKnownType(md protoreflect.MessageDescriptor, msg protoreflect.Message) (hash []byte, err error, ok bool) {
-	fullName := md.FullName()
+// wellKnownTypeHashFunc returns the function computing the hash of the named
+// well-known type, or nil if it is hashed as a regular message.
+func wellKnownTypeHashFunc(fullName protoreflect.FullName) func(*hasher, protoreflect.MessageDescriptor, protoreflect.Message) ([]byte, error) {
 	switch fullName {
 	case protoreflect.FullName("google.protobuf.Any"):
-		hash, err = h.hashGoogleProtobufAny(md, msg)
+		return (*hasher).hashGoogleProtobufAny
 	case protoreflect.FullName("google.protobuf.BoolValue"):
-		hash, err = h.hashGoogleProtobufBoolValue(md, msg)
+		return (*hasher).hashGoogleProtobufBoolValue
+	case protoreflect.FullName("google.protobuf.BytesValue"):
+		return (*hasher).hashGoogleProtobufBytesValue
 	case protoreflect.FullName("google.protobuf.DoubleValue"):
-		hash, err = h.hashGoogleProtobufDoubleValue(md, msg)
+		return (*hasher).hashGoogleProtobufDoubleValue
 	case protoreflect.FullName("google.protobuf.Duration"):
-		hash, err = h.hashGoogleProtobufDuration(md, msg)
+		return (*hasher).hashGoogleProtobufDuration
+	case protoreflect.FullName("google.protobuf.Empty"):
+		return (*hasher).hashGoogleProtobufEmpty
+	case protoreflect.FullName("google.protobuf.FieldMask"):
+		return (*hasher).hashGoogleProtobufFieldMask
 	case protoreflect.FullName("google.protobuf.FloatValue"):
-		hash, err = h.hashGoogleProtobufFloatValue(md, msg)
+		return (*hasher).hashGoogleProtobufFloatValue
 	case protoreflect.FullName("google.protobuf.Int32Value"):
-		hash, err = h.hashGoogleProtobufInt32Value(md, msg)
+		return (*hasher).hashGoogleProtobufInt32Value
 	case protoreflect.FullName("google.protobuf.ListValue"):
-		hash, err = h.hashGoogleProtobufListValue(md, msg)
+		return (*hasher).hashGoogleProtobufListValue
 	case protoreflect.FullName("google.protobuf.Int64Value"):
-		hash, err = h.hashGoogleProtobufInt64Value(md, msg)
+		return (*hasher).hashGoogleProtobufInt64Value
 	case protoreflect.FullName("google.protobuf.NullValue"):
-		hash, err = h.hashGoogleProtobufNullValue(md, msg)
+		return (*hasher).hashGoogleProtobufNullValue
 	case protoreflect.FullName("google.protobuf.StringValue"):
-		hash, err = h.hashGoogleProtobufStringValue(md, msg)
+		return (*hasher).hashGoogleProtobufStringValue
 	case protoreflect.FullName("google.protobuf.Struct"):
-		hash, err = h.hashGoogleProtobufStruct(md, msg)
+		return (*hasher).hashGoogleProtobufStruct
 	case protoreflect.FullName("google.protobuf.Timestamp"):
-		hash, err = h.hashGoogleProtobufTimestamp(md, msg)
+		return (*hasher).hashGoogleProtobufTimestamp
 	case protoreflect.FullName("google.protobuf.UInt32Value"):
-		hash, err = h.hashGoogleProtobufUint32Value(md, msg)
+		return (*hasher).hashGoogleProtobufUint32Value
 	case protoreflect.FullName("google.protobuf.UInt64Value"):
-		hash, err = h.hashGoogleProtobufUint64Value(md, msg)
+		return (*hasher).hashGoogleProtobufUint64Value
 	case protoreflect.FullName("google.protobuf.Value"):
-		hash, err = h.hashGoogleProtobufValue(md, msg)
-	default:
-		return nil, nil, false // no special handling needed, use hashMessage
+		return (*hasher).hashGoogleProtobufValue
 	}
-	return hash, err, true
+	return nil
 }
 
+func (h *hasher) getTypeResolver() protoregistry.MessageTypeResolver {
+	if h.typeResolver != nil {
+		return h.typeResolver
+	}
+	return protoregistry.GlobalTypes
+}
+
+// hashGoogleProtobufAny hashes an Any as if it were a message having the
+// normalized type URL in the type_url field and the unpacked message in the
+// value field.  Two Anys packing equal messages therefore hash identically,
+// regardless of how the packed message was serialized.
 func (h *hasher) hashGoogleProtobufAny(md protoreflect.MessageDescriptor, msg protoreflect.Message) ([]byte, error) {
-	// files := protoregistry.GlobalFiles - create option to set files explictly?
+	typeUrlFd := md.Fields().ByName("type_url")
+	valueFd := md.Fields().ByName(valueName)
+
+	typeUrl := msg.Get(typeUrlFd).String()
+	mt, err := h.getTypeResolver().FindMessageByURL(typeUrl)
+	if errors.Is(err, protoregistry.NotFound) {
+		return h.hashUnresolvedGoogleProtobufAny(md, msg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("resolving Any type %q: %w", typeUrl, err)
+	}
+
+	value := mt.New()
+	if err := h.unmarshalOptions().Unmarshal(msg.Get(valueFd).Bytes(), value.Interface()); err != nil {
+		return nil, fmt.Errorf("unmarshaling Any type %q: %w", typeUrl, err)
+	}
+	valueHash, err := h.hashMessage(value)
+	if err != nil {
+		return nil, fmt.Errorf("hashing Any type %q: %w", typeUrl, err)
+	}
+
+	return h.hashGoogleProtobufAnyFields(md, normalizeTypeURL(mt.Descriptor()), valueHash)
+}
+
+// unmarshalOptions returns the options used to unmarshal packed messages.
+// Extensions are resolved with the type resolver, if it is capable of doing so.
+func (h *hasher) unmarshalOptions() proto.UnmarshalOptions {
+	var opts proto.UnmarshalOptions
+	if resolver, ok := h.getTypeResolver().(protoregistry.ExtensionTypeResolver); ok {
+		opts.Resolver = resolver
+	}
+	return opts
+}
+
+// getExtensionTypeResolver returns the type resolver if it is capable of
+// resolving extensions, or protoregistry.GlobalTypes otherwise.
+func (h *hasher) getExtensionTypeResolver() protoregistry.ExtensionTypeResolver {
+	if resolver, ok := h.getTypeResolver().(protoregistry.ExtensionTypeResolver); ok {
+		return resolver
+	}
+	return protoregistry.GlobalTypes
+}
+
+// hashUnresolvedGoogleProtobufAny hashes an Any whose type URL cannot be
+// resolved, according to the unresolved Any policy.
+func (h *hasher) hashUnresolvedGoogleProtobufAny(md protoreflect.MessageDescriptor, msg protoreflect.Message) ([]byte, error) {
 	typeUrl := msg.Get(md.Fields().ByName("type_url")).String()
-	// TODO: lookup at a type server?
-	return nil, fmt.Errorf("protoreflecthash does not support hashing of Any type: " + typeUrl)
+	value := msg.Get(md.Fields().ByName(valueName)).Bytes()
+
+	var valueHash []byte
+	var err error
+	switch h.unresolvedAnyPolicy {
+	case UnresolvedAnyOpaque:
+		valueHash, err = h.hashBytes(value)
+	case UnresolvedAnyWire:
+		valueHash, err = h.hashRawMessage(value)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnresolvedAny, typeUrl)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("hashing Any type %q: %w", typeUrl, err)
+	}
+
+	return h.hashGoogleProtobufAnyFields(md, typeUrlName(typeUrl), valueHash)
+}
+
+// hashGoogleProtobufAnyFields computes the hash of an Any from its (normalized)
+// type URL and the hash of its value.
+func (h *hasher) hashGoogleProtobufAnyFields(md protoreflect.MessageDescriptor, typeUrl string, valueHash []byte) ([]byte, error) {
+	typeUrlFd := md.Fields().ByName("type_url")
+	valueFd := md.Fields().ByName(valueName)
+
+	typeUrlHash, err := h.hashField(typeUrlFd, protoreflect.ValueOfString(typeUrl))
+	if err != nil {
+		return nil, err
+	}
+	valueKeyHash, err := h.hashFieldKey(valueFd)
+	if err != nil {
+		return nil, fmt.Errorf("hashing field key %d (%s): %w", valueFd.Number(), valueFd.FullName(), err)
+	}
+
+	return h.hashFieldHashEntries(md, []*fieldHashEntry{
+		typeUrlHash,
+		{number: int32(valueFd.Number()), khash: valueKeyHash, vhash: valueHash},
+	})
+}
+
+// normalizeTypeURL returns the canonical type URL of the given message, which
+// is its fully-qualified name.  The (arbitrary) prefix of the URL is not part
+// of the hash, so "type.googleapis.com/foo.Bar" and "example.com/foo.Bar" are
+// equivalent.
+func normalizeTypeURL(md protoreflect.MessageDescriptor) string {
+	return string(md.FullName())
+}
+
+// typeUrlName returns the message name of a type URL, which is everything
+// following the last '/'.
+func typeUrlName(typeUrl string) string {
+	if i := strings.LastIndexByte(typeUrl, '/'); i >= 0 {
+		return typeUrl[i+1:]
+	}
+	return typeUrl
 }
 
 func (h *hasher) hashGoogleProtobufDuration(md protoreflect.MessageDescriptor, msg protoreflect.Message) ([]byte, error) {
@@ -364,18 +867,19 @@ func (h *hasher) hashGoogleProtobufTimestamp(md protoreflect.MessageDescriptor,
 }
 
 func (h *hasher) hashFieldsByName(md protoreflect.MessageDescriptor, msg protoreflect.Message, names ...string) ([]byte, error) {
-	var buf bytes.Buffer
+	w := h.digest.newNode(listIdentifier)
 
 	for _, name := range names {
 		value := msg.Get(md.Fields().ByName(protoreflect.Name(name)))
-		data, err := h.hashValue(protoreflect.Int32Kind, value)
+		data, err := h.appendValue(w.child(), protoreflect.Int32Kind, value)
 		if err != nil {
+			w.release()
 			return nil, fmt.Errorf("hashing %s: %w", md.FullName(), err)
 		}
-		buf.Write(data)
+		w.writeChild(data)
 	}
 
-	return hash(listIdentifier, buf.Bytes())
+	return w.appendSum(nil), nil
 }
 
 func (h *hasher) hashGoogleProtobufDoubleValue(md protoreflect.MessageDescriptor, msg protoreflect.Message) ([]byte, error) {
@@ -406,6 +910,58 @@ func (h *hasher) hashGoogleProtobufBoolValue(md protoreflect.MessageDescriptor,
 	return h.hashBool(msg.Get(md.Fields().ByName(valueName)).Bool())
 }
 
+func (h *hasher) hashGoogleProtobufBytesValue(md protoreflect.MessageDescriptor, msg protoreflect.Message) ([]byte, error) {
+	return h.hashBytes(msg.Get(md.Fields().ByName(valueName)).Bytes())
+}
+
+// hashGoogleProtobufEmpty hashes an Empty as an empty message.
+func (h *hasher) hashGoogleProtobufEmpty(md protoreflect.MessageDescriptor, msg protoreflect.Message) ([]byte, error) {
+	return h.hashFieldHashEntries(md, nil)
+}
+
+// hashGoogleProtobufFieldMask hashes a FieldMask as the set of its normalized
+// paths, so that the order of the paths is insignificant.
+func (h *hasher) hashGoogleProtobufFieldMask(md protoreflect.MessageDescriptor, msg protoreflect.Message) ([]byte, error) {
+	list := msg.Get(md.Fields().ByName("paths")).List()
+
+	paths := make([]string, list.Len())
+	for i := 0; i < list.Len(); i++ {
+		paths[i] = list.Get(i).String()
+	}
+
+	var hashes [][]byte
+	for _, path := range normalizeFieldMaskPaths(paths) {
+		data, err := h.hashString(path)
+		if err != nil {
+			return nil, fmt.Errorf("hashing field mask path %q: %w", path, err)
+		}
+		hashes = append(hashes, data)
+	}
+
+	return h.digest.hashSet(hashes)
+}
+
+// normalizeFieldMaskPaths returns the paths sorted, with duplicates and paths
+// covered by another path (such as "a.b" by "a") removed.  This is the same
+// canonical form as fieldmaskpb.FieldMask.Normalize.
+func normalizeFieldMaskPaths(paths []string) []string {
+	sorted := make([]string, len(paths))
+	copy(sorted, paths)
+	sort.Strings(sorted)
+
+	var normalized []string
+	for _, path := range sorted {
+		if n := len(normalized); n > 0 {
+			prev := normalized[n-1]
+			if path == prev || strings.HasPrefix(path, prev+".") {
+				continue
+			}
+		}
+		normalized = append(normalized, path)
+	}
+	return normalized
+}
+
 func (h *hasher) hashGoogleProtobufStringValue(md protoreflect.MessageDescriptor, msg protoreflect.Message) ([]byte, error) {
 	return h.hashString(msg.Get(md.Fields().ByName(valueName)).String())
 }
@@ -420,7 +976,7 @@ func (h *hasher) hashGoogleProtobufValue(md protoreflect.MessageDescriptor, msg
 
 	switch fd.Name() {
 	case "null_value":
-		return hashNil()
+		return h.hashNil()
 	case "number_value":
 		return h.hashFloat(value.Float())
 	case "string_value":
@@ -439,64 +995,56 @@ func (h *hasher) hashGoogleProtobufValue(md protoreflect.MessageDescriptor, msg
 func (h *hasher) hashGoogleProtobufListValue(md protoreflect.MessageDescriptor, msg protoreflect.Message) ([]byte, error) {
 	list := msg.Get(md.Fields().ByName("values")).List()
 
-	var buf bytes.Buffer
+	w := h.digest.newNode(listIdentifier)
 	for i := 0; i < list.Len(); i++ {
 		value := list.Get(i)
-		data, err := h.hashMessage(value.Message())
+		data, err := h.appendMessage(w.child(), value.Message())
 		if err != nil {
+			w.release()
 			return nil, fmt.Errorf("hashing list item %d: %w", i, err)
 		}
-		buf.Write(data)
+		w.writeChild(data)
 	}
 
-	return hash(listIdentifier, buf.Bytes())
+	return w.appendSum(nil), nil
 }
 
 func (h *hasher) hashGoogleProtobufNullValue(md protoreflect.MessageDescriptor, msg protoreflect.Message) ([]byte, error) {
-	return hashNil()
+	return h.hashNil()
 }
 
 func (h *hasher) hashGoogleProtobufStruct(md protoreflect.MessageDescriptor, msg protoreflect.Message) ([]byte, error) {
 	m := msg.Get(md.Fields().ByName("fields")).Map()
-	var mapHashEntries []hashMapEntry
+	w := h.digest.newNode(mapIdentifier)
+
+	entries := &w.st.entries
+	entries.buf = entries.buf[:0]
+	entries.size = w.st.hash.Size()
 
 	var errValue error
 	var errKey protoreflect.MapKey
 	m.Range(func(mk protoreflect.MapKey, v protoreflect.Value) bool {
-		khash, err := h.hashString(mk.String())
-		if err != nil {
-			errKey = mk
-			errValue = err
-			return false
-		}
+		entries.buf = h.digest.appendUnicode(entries.buf, mk.String())
 
-		vhash, err := h.hashMessage(v.Message())
+		var err error
+		entries.buf, err = h.appendMessage(entries.buf, v.Message())
 		if err != nil {
 			errKey = mk
 			errValue = err
 			return false
 		}
 
-		mapHashEntries = append(mapHashEntries, hashMapEntry{
-			khash: khash,
-			vhash: vhash,
-		})
-
 		return true
 	})
 	if errValue != nil {
+		w.release()
 		return nil, fmt.Errorf("hashing map key %v: %w", errKey, errValue)
 	}
 
-	sort.Sort(byKHash(mapHashEntries))
-
-	var buf bytes.Buffer
-	for _, e := range mapHashEntries {
-		buf.Write(e.khash[:])
-		buf.Write(e.vhash[:])
-	}
+	sort.Sort(entries)
+	w.write(entries.buf)
 
-	return hash(mapIdentifier, buf.Bytes())
+	return w.appendSum(nil), nil
 }
 
 type hashMapEntry struct {
@@ -3,21 +3,29 @@ package protoreflecthash
 import (
 	_ "embed"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"math"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/benlaurie/objecthash/go/objecthash"
 	"github.com/google/go-cmp/cmp"
 	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/protowire"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protodesc"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/runtime/protoimpl"
 	"google.golang.org/protobuf/types/descriptorpb"
 	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
 	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
 	"google.golang.org/protobuf/types/known/structpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 	"google.golang.org/protobuf/types/known/wrapperspb"
@@ -1014,6 +1022,86 @@ func TestHashOtherTypes(t *testing.T) {
 	}
 }
 
+func TestHashGroups(t *testing.T) {
+	for name, tc := range map[string]hashTestCase{
+		"optional group": {
+			options: []Option{FieldNamesAsKeys()},
+			protos: []proto.Message{
+				&pb2_latest.Groups{
+					Optionalgroup: &pb2_latest.Groups_OptionalGroup{StringField: proto.String("foo")},
+				},
+				&pb2_latest.GroupsAsMessages{
+					Optionalgroup: &pb2_latest.GroupsAsMessages_OptionalGroup{StringField: proto.String("foo")},
+				},
+			},
+			json: `{"optionalgroup": {"string_field": "foo"}}`,
+			obj:  map[string]map[string]string{"optionalgroup": {"string_field": "foo"}},
+			want: "7aba9ef130290fd0d4eeb2bb43dbdffc70b4f377dd38a5c2b6e9463f01bea387",
+		},
+		"nested group": {
+			options: []Option{FieldNamesAsKeys()},
+			protos: []proto.Message{
+				&pb2_latest.Groups{
+					Optionalgroup: &pb2_latest.Groups_OptionalGroup{
+						Nestedgroup: &pb2_latest.Groups_OptionalGroup_NestedGroup{Int32Field: proto.Int32(1)},
+					},
+				},
+				&pb2_latest.GroupsAsMessages{
+					Optionalgroup: &pb2_latest.GroupsAsMessages_OptionalGroup{
+						Nestedgroup: &pb2_latest.GroupsAsMessages_OptionalGroup_NestedGroup{Int32Field: proto.Int32(1)},
+					},
+				},
+			},
+			obj:  map[string]map[string]map[string]int32{"optionalgroup": {"nestedgroup": {"int32_field": 1}}},
+			want: "18ffe0b34c8b3ab846ffefd5189fd7da72937bc6bc316e4aa5806940abd64d4c",
+		},
+		"repeated group": {
+			options: []Option{FieldNamesAsKeys()},
+			protos: []proto.Message{
+				&pb2_latest.Groups{
+					Repeatedgroup: []*pb2_latest.Groups_RepeatedGroup{
+						{StringField: proto.String("foo")},
+						{StringField: proto.String("bar")},
+					},
+				},
+				&pb2_latest.GroupsAsMessages{
+					Repeatedgroup: []*pb2_latest.GroupsAsMessages_RepeatedGroup{
+						{StringField: proto.String("foo")},
+						{StringField: proto.String("bar")},
+					},
+				},
+			},
+			json: `{"repeatedgroup": [{"string_field": "foo"}, {"string_field": "bar"}]}`,
+			obj:  map[string][]map[string]string{"repeatedgroup": {{"string_field": "foo"}, {"string_field": "bar"}}},
+			want: "6a61d9a67490d151a9b8f700e415141d2369715588cb25e8e13d4ea13d403ad9",
+		},
+		"repeated nested group": {
+			protos: []proto.Message{
+				&pb2_latest.Groups{
+					Repeatedgroup: []*pb2_latest.Groups_RepeatedGroup{
+						{Nestedgroup: []*pb2_latest.Groups_RepeatedGroup_NestedGroup{
+							{Int32Field: proto.Int32(1)},
+							{Int32Field: proto.Int32(2)},
+						}},
+					},
+				},
+				&pb2_latest.GroupsAsMessages{
+					Repeatedgroup: []*pb2_latest.GroupsAsMessages_RepeatedGroup{
+						{Nestedgroup: []*pb2_latest.GroupsAsMessages_RepeatedGroup_NestedGroup{
+							{Int32Field: proto.Int32(1)},
+							{Int32Field: proto.Int32(2)},
+						}},
+					},
+				},
+			},
+			obj:  map[int64][]map[int64][]map[int64]int32{2: {{2: {{1: 1}, {1: 2}}}}},
+			want: "3eba240c42bc36a33c8769c4bbdc1c7bac73ca6eab976635528bbc1a6e07cd0a",
+		},
+	} {
+		tc.Check(name, t)
+	}
+}
+
 func TestHashTimestamp(t *testing.T) {
 	for name, tc := range map[string]hashTestCase{
 		"Empty/Zero Timestamps": {
@@ -1128,6 +1216,466 @@ func TestHashDuration(t *testing.T) {
 	}
 }
 
+func TestHashAny(t *testing.T) {
+	for name, tc := range map[string]hashTestCase{
+		"Any (hashing key field numbers)": {
+			// An Any is hashed as a message with the normalized type URL in the
+			// type_url field and the unpacked message in the value field.
+			// objecthash orders dict entries by key hash rather than field number,
+			// so there is no equivalent object.
+			protos: []proto.Message{
+				mustNewAny(t, &pb3_latest.Simple{StringField: "foo"}),
+				&anypb.Any{
+					TypeUrl: "example.com/schema.proto3.Simple",
+					Value:   mustMarshal(t, &pb3_latest.Simple{StringField: "foo"}),
+				},
+			},
+			want: "12ca8e2fd98d7b36c5dc647e12e33878c4d1c682d3bd4442b830a039aa7639d4",
+		},
+		"Any within other protos": {
+			options: []Option{FieldNamesAsKeys()},
+			protos: []proto.Message{
+				&pb2_latest.KnownTypes{AnyField: mustNewAny(t, &pb3_latest.Simple{StringField: "foo"})},
+				&pb3_latest.KnownTypes{AnyField: mustNewAny(t, &pb3_latest.Simple{StringField: "foo"})},
+			},
+			want: "785f03bfc0f476dac14d0332d0b36a78fcdea61c744703b3ff8c54d74759d6a5",
+		},
+		"Any (field order independence)": {
+			protos: []proto.Message{
+				mustNewAny(t, &pb3_latest.Simple{BoolField: true, StringField: "foo"}),
+				&anypb.Any{
+					TypeUrl: "type.googleapis.com/schema.proto3.Simple",
+					// string_field (25) serialized before bool_field (1).
+					Value: append(
+						mustMarshal(t, &pb3_latest.Simple{StringField: "foo"}),
+						mustMarshal(t, &pb3_latest.Simple{BoolField: true})...),
+				},
+			},
+			want: "728745e38a5311ee7ba88003d231c54985dbc228332099b0cd952af88198aac1",
+		},
+	} {
+		tc.Check(name, t)
+	}
+}
+
+func TestHashAnyTypeResolver(t *testing.T) {
+	files := unmarshalProtoRegistryFiles(t, testProtoset)
+	md := mdByPath(t, files, "test_protos/schema/proto3/simple.proto", "Simple")
+
+	packed := &anypb.Any{
+		TypeUrl: "type.googleapis.com/schema.proto3.Simple",
+		Value:   mustMarshal(t, unmarshalJson(t, md, `{"string_field": "foo"}`).Interface()),
+	}
+	want := getHash(t, func() ([]byte, error) {
+		return NewHasher().HashProto(mustNewAny(t, &pb3_latest.Simple{StringField: "foo"}).ProtoReflect())
+	})
+
+	for name, tc := range map[string]struct {
+		options []Option
+		wantErr bool
+	}{
+		"global types": {},
+		"files": {
+			options: []Option{TypeResolver(FilesTypeResolver(files))},
+		},
+		"empty types": {
+			options: []Option{TypeResolver(new(protoregistry.Types))},
+			wantErr: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			h := NewHasher(tc.options...)
+			hash, err := h.HashProto(packed.ProtoReflect())
+			if tc.wantErr {
+				if !errors.Is(err, ErrUnresolvedAny) {
+					t.Fatalf("want error %v, got %v", ErrUnresolvedAny, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(want, fmt.Sprintf("%x", hash)); diff != "" {
+				t.Errorf("protohash (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHashUnresolvedAny(t *testing.T) {
+	simple := mustMarshal(t, &pb3_latest.Simple{BoolField: true, StringField: "foo"})
+	// string_field (25) serialized before bool_field (1).
+	reordered := append(
+		mustMarshal(t, &pb3_latest.Simple{StringField: "foo"}),
+		mustMarshal(t, &pb3_latest.Simple{BoolField: true})...)
+
+	for name, tc := range map[string]struct {
+		options []Option
+		values  [][]byte
+		want    string
+		wantErr error
+	}{
+		"fail (default)": {
+			values:  [][]byte{simple},
+			wantErr: ErrUnresolvedAny,
+		},
+		"fail": {
+			options: []Option{UnresolvedAny(UnresolvedAnyFail)},
+			values:  [][]byte{simple},
+			wantErr: ErrUnresolvedAny,
+		},
+		"opaque": {
+			options: []Option{UnresolvedAny(UnresolvedAnyOpaque)},
+			values:  [][]byte{simple},
+			want:    "33d43ac2975b903d3625cf219c2075a63fae3ccabd8ead01d5009769fa20cc07",
+		},
+		"opaque (reordered)": {
+			options: []Option{UnresolvedAny(UnresolvedAnyOpaque)},
+			values:  [][]byte{reordered},
+			want:    "9f921f1fe7b93390857d278bb00bfe6adeca9761e9cbce23a0b87a4fefa1ad99",
+		},
+		"wire": {
+			options: []Option{UnresolvedAny(UnresolvedAnyWire)},
+			values:  [][]byte{simple, reordered},
+			want:    "eb76a24895056fdbdc550a2ad823725a8de49817e3c91565632c6d2f9c21d812",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			h := NewHasher(tc.options...)
+			for _, value := range tc.values {
+				packed := &anypb.Any{
+					TypeUrl: "type.googleapis.com/schema.proto3.Unknown",
+					Value:   value,
+				}
+				hash, err := h.HashProto(packed.ProtoReflect())
+				if tc.wantErr != nil {
+					if !errors.Is(err, tc.wantErr) {
+						t.Fatalf("want error %v, got %v", tc.wantErr, err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				if diff := cmp.Diff(tc.want, fmt.Sprintf("%x", hash)); diff != "" {
+					t.Errorf("protohash (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestHashRawMessage(t *testing.T) {
+	for name, tc := range map[string]struct {
+		msg proto.Message
+		obj interface{}
+	}{
+		"empty": {
+			msg: &pb3_latest.Simple{},
+			obj: map[int64]interface{}{},
+		},
+		"varint": {
+			msg: &pb3_latest.Simple{Int64Field: -1},
+			obj: map[int64]interface{}{15: []uint64{math.MaxUint64}},
+		},
+		"bytes": {
+			msg: &pb3_latest.Simple{StringField: "foo"},
+			obj: map[int64]interface{}{25: [][]byte{[]byte("foo")}},
+		},
+		"repeated": {
+			msg: &pb3_latest.Repetitive{Int32Field: []int32{1, 2, 3}},
+			// packed into a single bytes value
+			obj: map[int64]interface{}{13: [][]byte{{1, 2, 3}}},
+		},
+		"nested": {
+			msg: &pb3_latest.Simple{SimpleField: &pb3_latest.Simple{Fixed32Field: 7}},
+			obj: map[int64]interface{}{31: [][]byte{{0x3d, 7, 0, 0, 0}}},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			h := hasher{}
+
+			got := getHash(t, func() ([]byte, error) {
+				return h.hashRawMessage(mustMarshal(t, tc.msg))
+			})
+
+			if diff := cmp.Diff(objectHash(t, tc.obj), got); diff != "" {
+				t.Errorf("objecthash (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHashExtensions(t *testing.T) {
+	withExtensions := func(msg *pb2_latest.BadWithExtensions, extensions map[protoreflect.ExtensionType]interface{}) *pb2_latest.BadWithExtensions {
+		for xt, v := range extensions {
+			proto.SetExtension(msg, xt, v)
+		}
+		return msg
+	}
+
+	for name, tc := range map[string]hashTestCase{
+		"extension (hashing key field numbers)": {
+			protos: []proto.Message{
+				withExtensions(&pb2_latest.BadWithExtensions{}, map[protoreflect.ExtensionType]interface{}{
+					pb2_latest.E_StringExtension: "bar",
+				}),
+			},
+			obj:  map[int64]string{100: "bar"},
+			want: "6bf30170ec2cedee7958f31515c8d7d9c0c4d9b177789220d3f824072d19bf1d",
+		},
+		"extension (hashing key field names)": {
+			options: []Option{FieldNamesAsKeys()},
+			protos: []proto.Message{
+				withExtensions(&pb2_latest.BadWithExtensions{}, map[protoreflect.ExtensionType]interface{}{
+					pb2_latest.E_StringExtension: "bar",
+				}),
+			},
+			obj:  map[string]string{"schema.proto2.string_extension": "bar"},
+			json: `{"schema.proto2.string_extension": "bar"}`,
+			want: "2e0a9c8fcbd573a2498ed57a3b15622466b05985d1258de2968c74eb59e4ae39",
+		},
+		"repeated extension": {
+			options: []Option{FieldNamesAsKeys()},
+			protos: []proto.Message{
+				withExtensions(&pb2_latest.BadWithExtensions{}, map[protoreflect.ExtensionType]interface{}{
+					pb2_latest.E_Int32Extension: []int32{1, 2},
+				}),
+			},
+			obj:  map[string][]int32{"schema.proto2.int32_extension": {1, 2}},
+			want: "a0fc402a7dd05ece90abe215d7352eb3d62a8e25f7f5f5965dc9e85a2e449763",
+		},
+		"message extension": {
+			options: []Option{FieldNamesAsKeys()},
+			protos: []proto.Message{
+				withExtensions(&pb2_latest.BadWithExtensions{}, map[protoreflect.ExtensionType]interface{}{
+					pb2_latest.E_Extensions_SimpleExtension: &pb2_latest.Simple{StringField: proto.String("foo")},
+				}),
+			},
+			obj:  map[string]map[string]string{"schema.proto2.Extensions.simple_extension": {"string_field": "foo"}},
+			json: `{"schema.proto2.Extensions.simple_extension": {"string_field": "foo"}}`,
+			want: "2f830c04a934f608121d5486e7a51ede8cc3b5cfb99dc3fa21aa049711e5ba9d",
+		},
+		"fields and extensions": {
+			protos: []proto.Message{
+				withExtensions(&pb2_latest.BadWithExtensions{Text: proto.String("foo")}, map[protoreflect.ExtensionType]interface{}{
+					pb2_latest.E_StringExtension: "bar",
+				}),
+			},
+			want: "2d0745f3a4c4c87c8660c7717166b1c5d2c1d5edee366e8bb24dc9fc85602c73",
+		},
+	} {
+		tc.Check(name, t)
+	}
+}
+
+func TestHashAnyExtensions(t *testing.T) {
+	files := unmarshalProtoRegistryFiles(t, testProtoset)
+
+	msg := &pb2_latest.BadWithExtensions{Text: proto.String("foo")}
+	without := getHash(t, func() ([]byte, error) {
+		return NewHasher().HashProto(mustNewAny(t, msg).ProtoReflect())
+	})
+
+	proto.SetExtension(msg, pb2_latest.E_StringExtension, "bar")
+	packed := mustNewAny(t, msg)
+	want := getHash(t, func() ([]byte, error) {
+		return NewHasher().HashProto(packed.ProtoReflect())
+	})
+	if want == without {
+		t.Fatal("extension not included in Any hash")
+	}
+
+	got := getHash(t, func() ([]byte, error) {
+		return NewHasher(TypeResolver(FilesTypeResolver(files))).HashProto(packed.ProtoReflect())
+	})
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("protohash (-want +got):\n%s", diff)
+	}
+}
+
+func TestHashRejectExtensions(t *testing.T) {
+	msg := &pb2_latest.BadWithExtensions{Text: proto.String("foo")}
+	h := NewHasher(RejectExtensions())
+
+	if _, err := h.HashProto(msg.ProtoReflect()); err != nil {
+		t.Fatalf("unexpected error without extensions: %v", err)
+	}
+
+	proto.SetExtension(msg, pb2_latest.E_StringExtension, "bar")
+	if _, err := h.HashProto(msg.ProtoReflect()); !errors.Is(err, ErrExtensionsNotAllowed) {
+		t.Fatalf("want error %v, got %v", ErrExtensionsNotAllowed, err)
+	}
+}
+
+func TestHashUnknownFields(t *testing.T) {
+	unmarshalEmpty := func(msg proto.Message) *pb3_latest.Empty {
+		var empty pb3_latest.Empty
+		if err := proto.Unmarshal(mustMarshal(t, msg), &empty); err != nil {
+			t.Fatal(err)
+		}
+		return &empty
+	}
+
+	for name, tc := range map[string]hashTestCase{
+		"ignore (default)": {
+			protos: []proto.Message{
+				unmarshalEmpty(&pb3_latest.Simple{StringField: "foo"}),
+			},
+			json: `{}`,
+			want: "18ac3e7343f016890c510e93f935261169d9e3f565436429830faf0934f4f8e4",
+		},
+		"ignore": {
+			options: []Option{UnknownFields(UnknownFieldsIgnore)},
+			protos: []proto.Message{
+				unmarshalEmpty(&pb3_latest.Simple{StringField: "foo"}),
+			},
+			json: `{}`,
+			want: "18ac3e7343f016890c510e93f935261169d9e3f565436429830faf0934f4f8e4",
+		},
+		"include": {
+			options: []Option{UnknownFields(UnknownFieldsInclude)},
+			protos: []proto.Message{
+				unmarshalEmpty(&pb3_latest.Simple{StringField: "foo"}),
+			},
+			// Unknown fields are hashed as a list of their wire values.
+			obj:  map[int64][][]byte{25: {[]byte("foo")}},
+			want: "c23a4e46e5116f34f41b50c9b900b2534a886ef04736cb262615267c837fda69",
+		},
+		"include (nested)": {
+			options: []Option{UnknownFields(UnknownFieldsInclude)},
+			protos: []proto.Message{
+				&pb3_latest.KnownTypes{
+					AnyField: mustNewAny(t, unmarshalEmpty(&pb3_latest.Simple{Int32Field: 2})),
+				},
+			},
+			want: "203331304ba603ef0c87b44e19279f53e94b96e98faf82921f6652fe42bfae56",
+		},
+	} {
+		tc.Check(name, t)
+	}
+
+	t.Run("reject", func(t *testing.T) {
+		h := NewHasher(UnknownFields(UnknownFieldsReject))
+
+		if _, err := h.HashProto((&pb3_latest.Empty{}).ProtoReflect()); err != nil {
+			t.Fatalf("unexpected error without unknown fields: %v", err)
+		}
+
+		msg := unmarshalEmpty(&pb3_latest.Simple{BoolField: true, StringField: "foo"})
+		_, err := h.HashProto(msg.ProtoReflect())
+		if !errors.Is(err, ErrUnknownFields) {
+			t.Fatalf("want error %v, got %v", ErrUnknownFields, err)
+		}
+		if !strings.Contains(err.Error(), "schema.proto3.Empty [1 25]") {
+			t.Errorf("want error listing unknown field numbers, got %v", err)
+		}
+	})
+
+	t.Run("include (known field number)", func(t *testing.T) {
+		// Known fields sent with another wire type are unknown fields having
+		// the number of a known field.  With more than 12 entries, the order
+		// of the entries having the same number is not left to the sort.
+		msg := &pb3_latest.Repetitive{
+			BoolField:     []bool{true},
+			BytesField:    [][]byte{{1}},
+			DoubleField:   []float64{1},
+			Fixed32Field:  []uint32{1},
+			Fixed64Field:  []uint64{1},
+			FloatField:    []float32{1},
+			Int32Field:    []int32{1},
+			Int64Field:    []int64{1},
+			Sfixed32Field: []int32{1},
+			Sfixed64Field: []int64{1},
+			Sint32Field:   []int32{1},
+			Sint64Field:   []int64{1},
+			StringField:   []string{"a"},
+			Uint32Field:   []uint32{1},
+			Uint64Field:   []uint64{1},
+		}
+		data := mustMarshal(t, msg)
+		data = protowire.AppendFixed32(protowire.AppendTag(data, 13, protowire.Fixed32Type), 7)
+		data = protowire.AppendFixed32(protowire.AppendTag(data, 5, protowire.Fixed32Type), 7)
+		md := msg.ProtoReflect().Descriptor()
+		unmarshaled := dynamicpb.NewMessage(md)
+		if err := proto.Unmarshal(data, unmarshaled); err != nil {
+			t.Fatal(err)
+		}
+
+		h := NewHasher(UnknownFields(UnknownFieldsInclude))
+		want := getHash(t, func() ([]byte, error) {
+			return h.(WireProtoHasher).HashWire(md, data)
+		})
+		got := getHash(t, func() ([]byte, error) {
+			return h.HashProto(unmarshaled)
+		})
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("protohash (-want +got):\n%s", diff)
+		}
+	})
+
+	// Well-known types are subject to the unknown fields mode too.
+	unknown := mustMarshal(t, &pb3_latest.Simple{StringField: "foo"})
+	withUnknown := func(msg proto.Message) proto.Message {
+		msg = proto.Clone(msg)
+		msg.ProtoReflect().SetUnknown(unknown)
+		return msg
+	}
+
+	t.Run("reject (well-known types)", func(t *testing.T) {
+		h := NewHasher(UnknownFields(UnknownFieldsReject))
+
+		for _, msg := range []proto.Message{
+			&emptypb.Empty{},
+			wrapperspb.Int32(2),
+			&timestamppb.Timestamp{Seconds: 1},
+			mustNewAny(t, &pb3_latest.Simple{}),
+		} {
+			if _, err := h.HashProto(msg.ProtoReflect()); err != nil {
+				t.Fatalf("unexpected error without unknown fields: %v", err)
+			}
+			_, err := h.HashProto(withUnknown(msg).ProtoReflect())
+			if !errors.Is(err, ErrUnknownFields) {
+				t.Errorf("%T: want error %v, got %v", msg, ErrUnknownFields, err)
+			}
+		}
+	})
+
+	t.Run("include (well-known types)", func(t *testing.T) {
+		h := NewHasher(UnknownFields(UnknownFieldsInclude))
+
+		// An Empty with unknown fields hashes as any other message having
+		// them only.
+		want := getHash(t, func() ([]byte, error) {
+			return h.HashProto(unmarshalEmpty(&pb3_latest.Simple{StringField: "foo"}).ProtoReflect())
+		})
+		got := getHash(t, func() ([]byte, error) {
+			return h.HashProto(withUnknown(&emptypb.Empty{}).ProtoReflect())
+		})
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Empty (-want +got):\n%s", diff)
+		}
+
+		// Other well-known types are hashed as regular messages then, so that
+		// the unknown fields change the hash.
+		for _, msg := range []proto.Message{
+			wrapperspb.Int32(2),
+			&timestamppb.Timestamp{Seconds: 1},
+			mustNewAny(t, &pb3_latest.Simple{}),
+		} {
+			canonical := getHash(t, func() ([]byte, error) {
+				return h.HashProto(msg.ProtoReflect())
+			})
+			got := getHash(t, func() ([]byte, error) {
+				return h.HashProto(withUnknown(msg).ProtoReflect())
+			})
+			if got == canonical {
+				t.Errorf("%T: want unknown fields to change the hash", msg)
+			}
+		}
+	})
+}
+
 func TestHashBoolValue(t *testing.T) {
 	for name, tc := range map[string]hashTestCase{
 		"false": {
@@ -1272,6 +1820,89 @@ func TestHashMessageFullnameIdentifier(t *testing.T) {
 	}
 }
 
+func TestHashBytesValue(t *testing.T) {
+	for name, tc := range map[string]hashTestCase{
+		"empty": {
+			protos: []proto.Message{
+				&wrapperspb.BytesValue{},
+				&wrapperspb.BytesValue{Value: []byte{}},
+			},
+			obj:  []byte{},
+			want: "454349e422f05297191ead13e21d3db520e5abef52055e4964b82fb213f593a1",
+		},
+		"non-empty": {
+			protos: []proto.Message{
+				&wrapperspb.BytesValue{Value: []byte{0, 0, 0}},
+			},
+			obj:  []byte{0, 0, 0},
+			want: "d877bf4e5023a6df5262218800a7162e240c84e44696bb2c3ad1c5e756f3dac1",
+		},
+		"BytesValue within other protos": {
+			options: []Option{FieldNamesAsKeys()},
+			protos: []proto.Message{
+				&pb2_latest.KnownTypes{BytesValueField: &wrapperspb.BytesValue{Value: []byte{0, 0, 0}}},
+				&pb3_latest.KnownTypes{BytesValueField: &wrapperspb.BytesValue{Value: []byte{0, 0, 0}}},
+			},
+			// No equivalent JSON: JSON does not have a "bytes" type.
+			obj:  map[string][]byte{"bytes_value_field": {0, 0, 0}},
+			want: "ee4b465bf70c43eb3d775d07ff8f5d7871aabb05174b6bcb858a43985d0966d2",
+		},
+	} {
+		tc.Check(name, t)
+	}
+}
+
+func TestHashFieldMask(t *testing.T) {
+	for name, tc := range map[string]hashTestCase{
+		"empty": {
+			protos: []proto.Message{
+				&fieldmaskpb.FieldMask{},
+				&fieldmaskpb.FieldMask{Paths: []string{}},
+			},
+			obj:  objecthash.Set{},
+			want: "043a718774c572bd8a25adbeb1bfcd5c0256ae11cecf9f9c3f925d0e52beaf89",
+		},
+		"paths": {
+			protos: []proto.Message{
+				&fieldmaskpb.FieldMask{Paths: []string{"a.b", "c"}},
+				&fieldmaskpb.FieldMask{Paths: []string{"c", "a.b"}},
+				// duplicates
+				&fieldmaskpb.FieldMask{Paths: []string{"c", "a.b", "c"}},
+				// paths covered by another path
+				&fieldmaskpb.FieldMask{Paths: []string{"a.b.d", "c", "a.b", "c.e"}},
+				unmarshalJson(t, (&fieldmaskpb.FieldMask{}).ProtoReflect().Descriptor(), `"c,a.b"`).Interface(),
+			},
+			obj:  objecthash.Set{"a.b", "c"},
+			want: "0a58605136da3bba918d2ddce89a49e54fedb073e1c9cf5d6337f035932d53dc",
+		},
+	} {
+		tc.Check(name, t)
+	}
+}
+
+func TestHashEmptyWellKnownType(t *testing.T) {
+	for name, tc := range map[string]hashTestCase{
+		"empty": {
+			protos: []proto.Message{
+				&emptypb.Empty{},
+				&pb3_latest.Empty{},
+			},
+			json: `{}`,
+			obj:  map[string]string{},
+			want: "18ac3e7343f016890c510e93f935261169d9e3f565436429830faf0934f4f8e4",
+		},
+		"empty (with option)": {
+			options: []Option{MessageFullnameIdentifier()},
+			protos: []proto.Message{
+				&emptypb.Empty{},
+			},
+			want: "94a3b77dfa5d0585ed2604ec31b937488475803b9cf880b3e9255b657f156df8",
+		},
+	} {
+		tc.Check(name, t)
+	}
+}
+
 func TestHashStringValue(t *testing.T) {
 	for name, tc := range map[string]hashTestCase{
 		"empty": {
@@ -1904,6 +2535,215 @@ func TestHashRepeatedFields(t *testing.T) {
 	}
 }
 
+func TestHashPlaceholder(t *testing.T) {
+	files := unmarshalProtoRegistryFiles(t, testProtoset)
+
+	// outer.proto depends on simple.proto, which is not available when the
+	// partially-linked file is created.  The message type of the simple field is
+	// then a placeholder.
+	fdp := &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("outer.proto"),
+		Package:    proto.String("test"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"test_protos/schema/proto3/simple.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Outer"),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:     proto.String("simple"),
+				Number:   proto.Int32(1),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+				TypeName: proto.String(".schema.proto3.Simple"),
+			}},
+		}},
+	}
+	linked, err := protodesc.NewFile(fdp, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	partial, err := protodesc.FileOptions{AllowUnresolvable: true}.New(fdp, new(protoregistry.Files))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !partial.Messages().Get(0).Fields().Get(0).Message().IsPlaceholder() {
+		t.Fatal("expected placeholder message type")
+	}
+
+	data := protowire.AppendTag(nil, 1, protowire.BytesType)
+	data = protowire.AppendBytes(data, mustMarshal(t, &pb3_latest.Simple{StringField: "foo"}))
+	unmarshal := func(fd protoreflect.FileDescriptor) protoreflect.Message {
+		msg := dynamicpb.NewMessage(fd.Messages().Get(0))
+		if err := proto.Unmarshal(data, msg); err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	}
+
+	want := getHash(t, func() ([]byte, error) {
+		return NewHasher().HashProto(unmarshal(linked))
+	})
+
+	for name, tc := range map[string]struct {
+		options []Option
+		wantErr bool
+	}{
+		"global types": {},
+		"files": {
+			options: []Option{TypeResolver(FilesTypeResolver(files))},
+		},
+		"empty types": {
+			options: []Option{TypeResolver(new(protoregistry.Types))},
+			wantErr: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			h := NewHasher(tc.options...)
+			hash, err := h.HashProto(unmarshal(partial))
+			if tc.wantErr {
+				var placeholderErr *PlaceholderError
+				if !errors.As(err, &placeholderErr) {
+					t.Fatalf("want PlaceholderError, got %v", err)
+				}
+				if placeholderErr.FullName != "schema.proto3.Simple" {
+					t.Errorf("want placeholder schema.proto3.Simple, got %s", placeholderErr.FullName)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(want, fmt.Sprintf("%x", hash)); diff != "" {
+				t.Errorf("protohash (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHashWeakField(t *testing.T) {
+	files := unmarshalProtoRegistryFiles(t, testProtoset)
+
+	newFile := func(typeName string, weak bool) *descriptorpb.FileDescriptorProto {
+		fdp := &descriptorpb.FileDescriptorProto{
+			Name:       proto.String("weak.proto"),
+			Package:    proto.String("test"),
+			Syntax:     proto.String("proto2"),
+			Dependency: []string{"test_protos/schema/proto2/simple.proto"},
+			MessageType: []*descriptorpb.DescriptorProto{{
+				Name: proto.String("Weak"),
+				Field: []*descriptorpb.FieldDescriptorProto{{
+					Name:     proto.String("simple"),
+					Number:   proto.Int32(1),
+					Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+					Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+					TypeName: proto.String("." + typeName),
+				}},
+			}},
+		}
+		if weak {
+			fdp.WeakDependency = []int32{0}
+			fdp.MessageType[0].Field[0].Options = &descriptorpb.FieldOptions{Weak: proto.Bool(true)}
+		}
+		return fdp
+	}
+
+	data := protowire.AppendTag(nil, 1, protowire.BytesType)
+	data = protowire.AppendBytes(data, mustMarshal(t, &pb2_latest.Simple{StringField: proto.String("foo")}))
+	unmarshal := func(fd protoreflect.FileDescriptor) protoreflect.Message {
+		msg := dynamicpb.NewMessage(fd.Messages().Get(0))
+		if err := proto.Unmarshal(data, msg); err != nil {
+			t.Fatal(err)
+		}
+		return msg
+	}
+
+	// A weak field is hashed as the regular field it would be if its message
+	// type were linked.
+	linked, err := protodesc.NewFile(newFile("schema.proto2.Simple", false), files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := getHash(t, func() ([]byte, error) {
+		return NewHasher().HashProto(unmarshal(linked))
+	})
+
+	// test.Unlinked is only known to the files, not to the global registry.
+	unlinked, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("unlinked.proto"),
+		Package: proto.String("test"),
+		Syntax:  proto.String("proto2"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Unlinked"),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:   proto.String("string_field"),
+				Number: proto.Int32(25),
+				Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:   descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+			}},
+		}},
+	}, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := files.RegisterFile(unlinked); err != nil {
+		t.Fatal(err)
+	}
+
+	// Weak fields are rejected by protodesc, so the file is built the way
+	// generated code builds it.  The message type of a weak field is then
+	// resolved lazily, from protoregistry.GlobalFiles.
+	newWeakMessage := func(typeName string) protoreflect.Message {
+		raw := mustMarshal(t, newFile(typeName, true))
+		file := protoimpl.DescBuilder{RawDescriptor: raw, FileRegistry: new(protoregistry.Files)}.Build().File
+		if !file.Messages().Get(0).Fields().Get(0).IsWeak() {
+			t.Fatal("expected weak field")
+		}
+		return unmarshal(file)
+	}
+
+	for name, tc := range map[string]struct {
+		typeName string
+		options  []Option
+		wantErr  protoreflect.FullName
+	}{
+		"linked": {
+			typeName: "schema.proto2.Simple",
+		},
+		"linked, files": {
+			typeName: "schema.proto2.Simple",
+			options:  []Option{TypeResolver(FilesTypeResolver(files))},
+		},
+		"unlinked": {
+			typeName: "test.Unlinked",
+			wantErr:  "test.Unlinked",
+		},
+		"unlinked, files": {
+			typeName: "test.Unlinked",
+			options:  []Option{TypeResolver(FilesTypeResolver(files))},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			h := NewHasher(tc.options...)
+			hash, err := h.HashProto(newWeakMessage(tc.typeName))
+			if tc.wantErr != "" {
+				var placeholderErr *PlaceholderError
+				if !errors.As(err, &placeholderErr) {
+					t.Fatalf("want PlaceholderError, got %v", err)
+				}
+				if placeholderErr.FullName != tc.wantErr {
+					t.Errorf("want placeholder %s, got %s", tc.wantErr, placeholderErr.FullName)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(want, fmt.Sprintf("%x", hash)); diff != "" {
+				t.Errorf("protohash (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
 func TestHashProtoReflectMessage(t *testing.T) {
 	files := unmarshalProtoRegistryFiles(t, testProtoset)
 
@@ -1942,6 +2782,117 @@ func TestHashProtoReflectMessage(t *testing.T) {
 
 }
 
+// benchmarkMessages are the messages hashed by the benchmarks, chosen to
+// exercise scalars, repeated fields, maps, nesting and well-known types.
+func benchmarkMessages(b *testing.B) map[string]proto.Message {
+	repetitive := &pb3_latest.Repetitive{}
+	maps := &pb3_latest.StringMaps{
+		StringToString: map[string]string{},
+		StringToInt32:  map[string]int32{},
+	}
+	for i := 0; i < 100; i++ {
+		repetitive.Int32Field = append(repetitive.Int32Field, int32(i*1000))
+		repetitive.DoubleField = append(repetitive.DoubleField, float64(i)/3)
+		repetitive.StringField = append(repetitive.StringField, fmt.Sprintf("value %d", i))
+		maps.StringToString[fmt.Sprintf("key %d", i)] = fmt.Sprintf("value %d", i)
+		maps.StringToInt32[fmt.Sprintf("key %d", i)] = int32(i)
+	}
+
+	person := &pb3_latest.PersonV4{Id: 1, Age: 40, Profession: "engineer"}
+	for i := 0; i < 10; i++ {
+		person.Children = append(person.Children, &pb3_latest.PersonV3{
+			Id:   int32(i),
+			Age:  uint32(i),
+			Name: &pb3_latest.PersonV3_FullName{FullName: fmt.Sprintf("child %d", i)},
+		})
+	}
+
+	st, err := structpb.NewStruct(map[string]interface{}{
+		"name":  "value",
+		"count": 3.0,
+		"tags":  []interface{}{"a", "b", true, nil},
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return map[string]proto.Message{
+		"scalars": &pb3_latest.Simple{
+			BoolField:   true,
+			BytesField:  []byte("bytes"),
+			DoubleField: math.Pi,
+			Int32Field:  -42,
+			Int64Field:  math.MaxInt64,
+			StringField: "string",
+			Uint64Field: math.MaxUint64,
+		},
+		"repeated":         repetitive,
+		"maps":             maps,
+		"nested":           person,
+		"well-known types": &pb3_latest.KnownTypes{StructField: st, TimestampField: timestamppb.New(time.Unix(1, 2))},
+	}
+}
+
+func TestHashProtoAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("hash states are not reliably reused with the race detector")
+	}
+	h := NewHasher().(AppendingProtoHasher)
+	buf := make([]byte, 0, 64)
+	allocs := func(msg proto.Message) float64 {
+		return testing.AllocsPerRun(100, func() {
+			var err error
+			if buf, err = h.AppendHash(buf[:0], msg.ProtoReflect()); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	// The hashes of scalars are written to the hash of their parent as they
+	// are computed, so hashing them allocates nothing.
+	one := allocs(&pb3_latest.Repetitive{Int32Field: []int32{1}, DoubleField: []float64{0.5}})
+	many := &pb3_latest.Repetitive{}
+	for i := 0; i < 100; i++ {
+		many.Int32Field = append(many.Int32Field, int32(i))
+		many.DoubleField = append(many.DoubleField, float64(i)/3)
+	}
+	if got := allocs(many); got != one {
+		t.Errorf("want %v allocations regardless of the number of elements, got %v", one, got)
+	}
+}
+
+func BenchmarkHashProto(b *testing.B) {
+	for name, msg := range benchmarkMessages(b) {
+		b.Run(name, func(b *testing.B) {
+			h := NewHasher()
+			m := msg.ProtoReflect()
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := h.HashProto(m); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkAppendHash(b *testing.B) {
+	for name, msg := range benchmarkMessages(b) {
+		b.Run(name, func(b *testing.B) {
+			h := NewHasher().(AppendingProtoHasher)
+			m := msg.ProtoReflect()
+			buf := make([]byte, 0, 64)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				var err error
+				if buf, err = h.AppendHash(buf[:0], m); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
 func unmarshalJson(t *testing.T, md protoreflect.MessageDescriptor, json string) protoreflect.Message {
 	msg := dynamicpb.NewMessage(md)
 	if err := protojson.Unmarshal([]byte(json), msg); err != nil {
@@ -1950,6 +2901,22 @@ func unmarshalJson(t *testing.T, md protoreflect.MessageDescriptor, json string)
 	return msg
 }
 
+func mustNewAny(t *testing.T, msg proto.Message) *anypb.Any {
+	packed, err := anypb.New(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return packed
+}
+
+func mustMarshal(t *testing.T, msg proto.Message) []byte {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
 func fdByPath(t *testing.T, files *protoregistry.Files, filename string) protoreflect.FileDescriptor {
 	fd, err := files.FindFileByPath(filename)
 	if err != nil {
@@ -1,9 +1,15 @@
 package protoreflecthash
 
 import (
+	"bytes"
+	"crypto/hmac"
 	"crypto/sha256"
 	"fmt"
+	"hash"
 	"math"
+	"sort"
+	"strconv"
+	"sync"
 )
 
 const (
@@ -15,84 +21,328 @@ const (
 	listIdentifier     = `l`
 	nilIdentifier      = `n`
 	byteIdentifier     = `r`
+	setIdentifier      = `s`
 	unicodeIndentifier = `u`
 )
 
-func hashBool(b bool) ([]byte, error) {
-	bb := []byte(`0`)
+// digest computes the hash of each node of the object tree.  The zero value
+// uses SHA-256.
+type digest struct {
+	// newHash constructs the hash function applied to each node.
+	newHash func() hash.Hash
+	// key, if set, is the key under which each node is hashed with HMAC.
+	key []byte
+	// observe, if set, is called with the identifier, pre-image and resulting
+	// hash of each node.  The pre-image and hash are copies that the callee may
+	// retain.
+	observe func(t string, b []byte, sum []byte)
+	// states, if set, pools the hash states of the digest.  It is specific to
+	// the hash function and key, so it is reset along with them.
+	states *sync.Pool
+}
+
+// digestState is a hash state together with scratch buffers, reused from node
+// to node so that hashing a node allocates nothing but its result.
+type digestState struct {
+	hash hash.Hash
+	// pre holds the identifier and pre-image of a leaf, or of a composite node
+	// being observed.
+	pre []byte
+	// child holds the hash of the child being written to a composite node.
+	child []byte
+	// entries holds the key and value hashes of the entries of a map, to be
+	// sorted before being written.
+	entries entrySorter
+}
+
+// pool enables the reuse of the hash states of the digest.
+func (d *digest) pool() {
+	config := digest{newHash: d.newHash, key: d.key}
+	d.states = &sync.Pool{
+		New: func() interface{} {
+			return &digestState{hash: config.newNodeHash()}
+		},
+	}
+}
+
+// withKey returns a copy of the digest hashing under the given key (or
+// without a key, if nil).
+func (d digest) withKey(key []byte) digest {
+	d.key = key
+	d.states = nil
+	return d
+}
+
+// get returns a hash state in its initial state.
+func (d *digest) get() *digestState {
+	if d.states == nil {
+		return &digestState{hash: d.newNodeHash()}
+	}
+	st := d.states.Get().(*digestState)
+	st.hash.Reset()
+	return st
+}
+
+// put releases a hash state for reuse.
+func (d *digest) put(st *digestState) {
+	if d.states != nil {
+		d.states.Put(st)
+	}
+}
+
+// notify calls the observer, if any, with copies of the pre-image and hash of a
+// node.
+func (d *digest) notify(t string, b []byte, sum []byte) {
+	if d.observe != nil {
+		d.observe(t, append([]byte{}, b...), append([]byte{}, sum...))
+	}
+}
+
+// appendLeaf appends to dst the hash of a leaf, whose identifier and pre-image
+// have been formatted into the pre buffer of st, and releases st.
+func (d *digest) appendLeaf(dst []byte, st *digestState, t string) []byte {
+	st.hash.Write(st.pre)
+	n := len(dst)
+	dst = st.hash.Sum(dst)
+	d.notify(t, st.pre[len(t):], dst[n:])
+	d.put(st)
+	return dst
+}
+
+func (d *digest) appendBool(dst []byte, b bool) []byte {
+	st := d.get()
+	st.pre = append(st.pre[:0], boolIdentifier...)
 	if b {
-		bb = []byte(`1`)
+		st.pre = append(st.pre, '1')
+	} else {
+		st.pre = append(st.pre, '0')
 	}
-	return hash(boolIdentifier, bb)
+	return d.appendLeaf(dst, st, boolIdentifier)
 }
 
-func hashBytes(bs []byte) ([]byte, error) {
-	return hash(byteIdentifier, bs)
+func (d *digest) appendBytes(dst []byte, bs []byte) []byte {
+	return d.appendHash(dst, byteIdentifier, bs)
 }
 
-func hashFloat(f float64) ([]byte, error) {
-	var normalizedFloat string
+func (d *digest) appendFloat(dst []byte, f float64) ([]byte, error) {
+	st := d.get()
+	st.pre = append(st.pre[:0], floatIdentifier...)
 
 	switch {
 	case math.IsInf(f, 1):
-		normalizedFloat = "Infinity"
+		st.pre = append(st.pre, "Infinity"...)
 	case math.IsInf(f, -1):
-		normalizedFloat = "-Infinity"
+		st.pre = append(st.pre, "-Infinity"...)
 	case math.IsNaN(f):
-		normalizedFloat = "NaN"
+		st.pre = append(st.pre, "NaN"...)
 	default:
 		var err error
-		normalizedFloat, err = floatNormalize(f)
+		st.pre, err = appendNormalizedFloat(st.pre, f)
 		if err != nil {
+			d.put(st)
 			return nil, err
 		}
 	}
 
-	return hash(floatIdentifier, []byte(normalizedFloat))
+	return d.appendLeaf(dst, st, floatIdentifier), nil
+}
+
+func (d *digest) appendUint64(dst []byte, i uint64) []byte {
+	st := d.get()
+	st.pre = strconv.AppendUint(append(st.pre[:0], intIdentifier...), i, 10)
+	return d.appendLeaf(dst, st, intIdentifier)
+}
+
+func (d *digest) appendInt64(dst []byte, i int64) []byte {
+	st := d.get()
+	st.pre = strconv.AppendInt(append(st.pre[:0], intIdentifier...), i, 10)
+	return d.appendLeaf(dst, st, intIdentifier)
+}
+
+func (d *digest) appendNil(dst []byte) []byte {
+	return d.appendHash(dst, nilIdentifier, nil)
+}
+
+func (d *digest) appendUnicode(dst []byte, s string) []byte {
+	st := d.get()
+	st.pre = append(append(st.pre[:0], unicodeIndentifier...), s...)
+	return d.appendLeaf(dst, st, unicodeIndentifier)
+}
+
+func (d *digest) hashBool(b bool) ([]byte, error) {
+	return d.appendBool(nil, b), nil
+}
+
+func (d *digest) hashBytes(bs []byte) ([]byte, error) {
+	return d.appendBytes(nil, bs), nil
+}
+
+func (d *digest) hashFloat(f float64) ([]byte, error) {
+	return d.appendFloat(nil, f)
 }
 
-func hashUint64(i uint64) ([]byte, error) {
-	return hash(intIdentifier, []byte(fmt.Sprintf("%d", i)))
+func (d *digest) hashUint64(i uint64) ([]byte, error) {
+	return d.appendUint64(nil, i), nil
 }
 
-func hashInt64(i int64) ([]byte, error) {
-	return hash(intIdentifier, []byte(fmt.Sprintf("%d", i)))
+func (d *digest) hashInt64(i int64) ([]byte, error) {
+	return d.appendInt64(nil, i), nil
 }
 
-func hashNil() ([]byte, error) {
-	return hash(nilIdentifier, []byte(``))
+func (d *digest) hashNil() ([]byte, error) {
+	return d.appendNil(nil), nil
 }
 
-func hashUnicode(s string) ([]byte, error) {
-	return hash(unicodeIndentifier, []byte(s))
+func (d *digest) hashUnicode(s string) ([]byte, error) {
+	return d.appendUnicode(nil, s), nil
 }
 
-func hash(t string, b []byte) ([]byte, error) {
-	h := sha256.New()
+// hashSet computes the hash of an unordered, unduplicated collection from the
+// hashes of its elements.
+func (d *digest) hashSet(hashes [][]byte) ([]byte, error) {
+	sorted := make([][]byte, len(hashes))
+	copy(sorted, hashes)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i], sorted[j]) < 0
+	})
 
-	if _, err := h.Write([]byte(t)); err != nil {
-		return nil, err
+	w := d.newNode(setIdentifier)
+	var prev []byte
+	for _, h := range sorted {
+		if !bytes.Equal(h, prev) {
+			w.write(h)
+		}
+		prev = h
 	}
 
-	if _, err := h.Write(b); err != nil {
-		return nil, err
+	return w.appendSum(nil), nil
+}
+
+func (d *digest) hash(t string, b []byte) ([]byte, error) {
+	return d.appendHash(nil, t, b), nil
+}
+
+// appendHash appends to dst the hash of the node having the given identifier
+// and pre-image.
+func (d *digest) appendHash(dst []byte, t string, b []byte) []byte {
+	st := d.get()
+	st.pre = append(st.pre[:0], t...)
+	st.hash.Write(st.pre)
+	st.hash.Write(b)
+
+	n := len(dst)
+	dst = st.hash.Sum(dst)
+	d.notify(t, b, dst[n:])
+	d.put(st)
+	return dst
+}
+
+func (d *digest) newNodeHash() hash.Hash {
+	newHash := d.newHash
+	if newHash == nil {
+		newHash = sha256.New
+	}
+	if d.key != nil {
+		return hmac.New(newHash, d.key)
 	}
+	return newHash()
+}
+
+// nodeWriter streams the pre-image of a composite node into its hash state as
+// the hashes of its children are computed, rather than buffering them.
+type nodeWriter struct {
+	d  *digest
+	st *digestState
+	t  string
+}
+
+// newNode starts a composite node having the given identifier.
+func (d *digest) newNode(t string) nodeWriter {
+	st := d.get()
+	st.pre = append(st.pre[:0], t...)
+	st.hash.Write(st.pre)
+	return nodeWriter{d: d, st: st, t: t}
+}
+
+// write appends b to the pre-image of the node.
+func (w nodeWriter) write(b []byte) {
+	w.st.hash.Write(b)
+	if w.d.observe != nil {
+		w.st.pre = append(w.st.pre, b...)
+	}
+}
 
-	return h.Sum(nil), nil
+// child returns an empty scratch buffer for the hash of a child, to be written
+// with writeChild.
+func (w nodeWriter) child() []byte {
+	return w.st.child[:0]
 }
 
-func floatNormalize(originalFloat float64) (string, error) {
+// writeChild appends the hash of a child to the pre-image of the node.  The
+// hash must have been appended to the buffer returned by child, which is
+// reused for the next child.
+func (w nodeWriter) writeChild(hash []byte) {
+	w.st.child = hash
+	w.write(hash)
+}
+
+// appendSum appends the hash of the node to dst and releases the node.
+func (w nodeWriter) appendSum(dst []byte) []byte {
+	n := len(dst)
+	dst = w.st.hash.Sum(dst)
+	w.d.notify(w.t, w.st.pre[len(w.t):], dst[n:])
+	w.d.put(w.st)
+	return dst
+}
+
+// release releases the node without computing its hash, as when hashing one
+// of its children failed.
+func (w nodeWriter) release() {
+	w.d.put(w.st)
+}
+
+// entrySorter sorts a buffer of consecutive key and value hash pairs by key
+// hash.
+type entrySorter struct {
+	buf  []byte
+	size int
+	tmp  []byte
+}
+
+func (s *entrySorter) entry(i int) []byte {
+	return s.buf[2*s.size*i : 2*s.size*(i+1)]
+}
+
+func (s *entrySorter) Len() int {
+	return len(s.buf) / (2 * s.size)
+}
+
+func (s *entrySorter) Less(i, j int) bool {
+	return bytes.Compare(s.entry(i)[:s.size], s.entry(j)[:s.size]) < 0
+}
+
+func (s *entrySorter) Swap(i, j int) {
+	a, b := s.entry(i), s.entry(j)
+	s.tmp = append(s.tmp[:0], a...)
+	copy(a, b)
+	copy(b, s.tmp)
+}
+
+// appendNormalizedFloat appends the normalized form of a finite float to dst.
+func appendNormalizedFloat(dst []byte, originalFloat float64) ([]byte, error) {
 	// Special case 0
 	// Note that if we allowed f to end up > .5 or == 0, we'd get the same thing.
 	if originalFloat == 0 {
-		return "+0:", nil
+		return append(dst, "+0:"...), nil
 	}
 
+	start := len(dst)
+
 	// Sign
 	f := originalFloat
-	s := `+`
+	s := append(dst, '+')
 	if f < 0 {
-		s = `-`
+		s[start] = '-'
 		f = -f
 	}
 	// Exponent
@@ -105,23 +355,23 @@ func floatNormalize(originalFloat float64) (string, error) {
 		f *= 2
 		e--
 	}
-	s += fmt.Sprintf("%d:", e)
+	s = append(strconv.AppendInt(s, int64(e), 10), ':')
 	// Mantissa
 	if f > 1 || f <= .5 {
-		return "", fmt.Errorf("could not normalize float: %f", originalFloat)
+		return nil, fmt.Errorf("could not normalize float: %f", originalFloat)
 	}
 	for f != 0 {
 		if f >= 1 {
-			s += `1`
+			s = append(s, '1')
 			f--
 		} else {
-			s += `0`
+			s = append(s, '0')
 		}
 		if f >= 1 {
-			return "", fmt.Errorf("could not normalize float: %f", originalFloat)
+			return nil, fmt.Errorf("could not normalize float: %f", originalFloat)
 		}
-		if len(s) >= 1000 {
-			return "", fmt.Errorf("could not normalize float: %f", originalFloat)
+		if len(s)-start >= 1000 {
+			return nil, fmt.Errorf("could not normalize float: %f", originalFloat)
 		}
 		f *= 2
 	}
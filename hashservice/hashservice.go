@@ -0,0 +1,236 @@
+// Package hashservice implements the protoreflecthash.v1.HashService gRPC
+// service, defined in proto/protoreflecthash/v1/hash_service.proto, with a
+// hasher returned by protoreflecthash.NewHasher:
+//
+//	s := grpc.NewServer()
+//	protoreflecthashv1.RegisterHashServiceServer(s, hashservice.New(files))
+package hashservice
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/stackb/protoreflecthash"
+	pb "github.com/stackb/protoreflecthash/proto/protoreflecthash/v1"
+)
+
+// Server implements protoreflecthashv1.HashServiceServer.
+type Server struct {
+	pb.UnimplementedHashServiceServer
+
+	files    *protoregistry.Files
+	resolver resolver
+	hasher   protoreflecthash.ProtoHasher
+}
+
+// resolver resolves the message types and extensions of messages in binary
+// form, and the types of google.protobuf.Any messages.
+type resolver interface {
+	protoregistry.MessageTypeResolver
+	protoregistry.ExtensionTypeResolver
+}
+
+// New returns a server hashing messages of the types described by files, with
+// a hasher created with the given options.  The types of the
+// google.protobuf.Any messages are resolved in files, unless the options
+// include another TypeResolver.
+func New(files *protoregistry.Files, options ...protoreflecthash.Option) *Server {
+	resolver := protoreflecthash.FilesTypeResolver(files).(resolver)
+	options = append([]protoreflecthash.Option{protoreflecthash.TypeResolver(resolver)}, options...)
+	return &Server{
+		files:    files,
+		resolver: resolver,
+		hasher:   protoreflecthash.NewHasher(options...),
+	}
+}
+
+// Hash implements protoreflecthashv1.HashServiceServer.
+func (s *Server) Hash(ctx context.Context, req *pb.HashRequest) (*pb.HashResponse, error) {
+	md, value, err := s.resolve(req.GetMessage())
+	if err != nil {
+		return nil, err
+	}
+	resp := &pb.HashResponse{TypeName: string(md.FullName())}
+
+	if !req.GetTree() {
+		// Binary messages are hashed without being decoded.
+		if resp.Hash, err = s.hasher.(protoreflecthash.WireProtoHasher).HashWire(md, value); err != nil {
+			return nil, hashError(err)
+		}
+		return resp, nil
+	}
+
+	msg, err := s.decode(md, value)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := s.hasher.(protoreflecthash.TreeProtoHasher).HashTree(msg)
+	if err != nil {
+		return nil, hashError(err)
+	}
+	resp.Hash = tree.Hash
+	resp.Tree = nodeProto(tree)
+	return resp, nil
+}
+
+// HashBatch implements protoreflecthashv1.HashServiceServer.
+func (s *Server) HashBatch(ctx context.Context, req *pb.HashBatchRequest) (*pb.HashBatchResponse, error) {
+	resp := &pb.HashBatchResponse{Results: make([]*pb.HashResult, len(req.GetMessages()))}
+	for i, m := range req.GetMessages() {
+		if err := ctx.Err(); err != nil {
+			return nil, status.FromContextError(err).Err()
+		}
+		md, value, err := s.resolve(m)
+		var hash []byte
+		if err == nil {
+			if hash, err = s.hasher.(protoreflecthash.WireProtoHasher).HashWire(md, value); err != nil {
+				err = hashError(err)
+			}
+		}
+		if err != nil {
+			resp.Results[i] = &pb.HashResult{Result: &pb.HashResult_Error{Error: status.Convert(err).Message()}}
+		} else {
+			resp.Results[i] = &pb.HashResult{Result: &pb.HashResult_Hash{Hash: hash}}
+		}
+	}
+	return resp, nil
+}
+
+// Diff implements protoreflecthashv1.HashServiceServer.
+func (s *Server) Diff(ctx context.Context, req *pb.DiffRequest) (*pb.DiffResponse, error) {
+	a, err := s.message(req.GetA())
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.message(req.GetB())
+	if err != nil {
+		return nil, err
+	}
+	diff, err := s.hasher.(protoreflecthash.DiffingProtoHasher).Diff(a, b)
+	if err != nil {
+		return nil, hashError(err)
+	}
+	return &pb.DiffResponse{Paths: diff.Paths, FieldMask: diff.FieldMask}, nil
+}
+
+// Prove implements protoreflecthashv1.HashServiceServer.
+func (s *Server) Prove(ctx context.Context, req *pb.ProveRequest) (*pb.ProveResponse, error) {
+	msg, err := s.message(req.GetMessage())
+	if err != nil {
+		return nil, err
+	}
+	hash, err := s.hasher.HashProto(msg)
+	if err != nil {
+		return nil, hashError(err)
+	}
+
+	prover := s.hasher.(protoreflecthash.ProvingProtoHasher)
+	var proof *protoreflecthash.Proof
+	if req.GetAbsence() {
+		proof, err = prover.ProveAbsence(msg, req.GetPath())
+	} else {
+		proof, err = prover.Prove(msg, req.GetPath())
+	}
+	if err != nil {
+		return nil, hashError(err)
+	}
+	return &pb.ProveResponse{Hash: hash, Proof: proofProto(proof)}, nil
+}
+
+// resolve returns the type and binary encoding of a message of a request.
+func (s *Server) resolve(m *pb.Message) (protoreflect.MessageDescriptor, []byte, error) {
+	switch m := m.GetMessage().(type) {
+	case *pb.Message_Any:
+		mt, err := s.resolver.FindMessageByURL(m.Any.GetTypeUrl())
+		if err != nil {
+			return nil, nil, status.Errorf(codes.NotFound, "unknown message type %q", m.Any.GetTypeUrl())
+		}
+		return mt.Descriptor(), m.Any.GetValue(), nil
+	case *pb.Message_Encoded:
+		md, err := s.messageType(m.Encoded.GetTypeName())
+		if err != nil {
+			return nil, nil, err
+		}
+		return md, m.Encoded.GetValue(), nil
+	}
+	return nil, nil, status.Error(codes.InvalidArgument, "message not set")
+}
+
+// messageType returns the descriptor of the named message type.
+func (s *Server) messageType(name string) (protoreflect.MessageDescriptor, error) {
+	d, err := s.files.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "unknown message type %q", name)
+	}
+	md, ok := d.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "%s is not a message type", name)
+	}
+	return md, nil
+}
+
+// message returns the decoded message of a request.
+func (s *Server) message(m *pb.Message) (protoreflect.Message, error) {
+	md, value, err := s.resolve(m)
+	if err != nil {
+		return nil, err
+	}
+	return s.decode(md, value)
+}
+
+// decode decodes a message of the given type from its binary encoding.
+func (s *Server) decode(md protoreflect.MessageDescriptor, value []byte) (protoreflect.Message, error) {
+	msg := dynamicpb.NewMessage(md)
+	if err := (proto.UnmarshalOptions{Resolver: s.resolver, AllowPartial: true}).Unmarshal(value, msg); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "parsing %s message: %v", md.FullName(), err)
+	}
+	return msg, nil
+}
+
+// hashError returns the status error for an error of the hasher, which is
+// caused by the request, unless it is a google.protobuf.Any whose type is not
+// known to the server.
+func hashError(err error) error {
+	if errors.Is(err, protoreflecthash.ErrUnresolvedAny) {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return status.Error(codes.InvalidArgument, err.Error())
+}
+
+// nodeProto converts a node of a hash tree, and its descendants, to its
+// message form.
+func nodeProto(node *protoreflecthash.HashNode) *pb.HashNode {
+	m := &pb.HashNode{
+		Path:       node.Path,
+		Identifier: node.Identifier,
+		Key:        node.Key,
+		KeyHash:    node.KeyHash,
+		Hash:       node.Hash,
+		Value:      node.Value,
+	}
+	for _, child := range node.Children {
+		m.Children = append(m.Children, nodeProto(child))
+	}
+	return m
+}
+
+// proofProto converts a proof to its message form.
+func proofProto(proof *protoreflecthash.Proof) *pb.Proof {
+	m := &pb.Proof{}
+	for _, step := range proof.Steps {
+		m.Steps = append(m.Steps, &pb.ProofStep{
+			Identifier:  step.Identifier,
+			FieldNumber: step.FieldNumber,
+			Siblings:    step.Siblings,
+			Index:       int32(step.Index),
+		})
+	}
+	return m
+}
@@ -0,0 +1,297 @@
+package hashservice
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+
+	"github.com/stackb/protoreflecthash"
+	pb "github.com/stackb/protoreflecthash/proto/protoreflecthash/v1"
+	pb3_latest "github.com/stackb/protoreflecthash/test_protos/generated/latest/proto3"
+)
+
+// loadFiles loads the descriptors of the test protoset.
+func loadFiles(t *testing.T) *protoregistry.Files {
+	t.Helper()
+	data, err := ioutil.ReadFile("../testdata/protoset.pb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		t.Fatal(err)
+	}
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// newClient serves a server created with the given options over an in-memory
+// connection and returns a client of it.
+func newClient(t *testing.T, options ...protoreflecthash.Option) pb.HashServiceClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	pb.RegisterHashServiceServer(s, New(loadFiles(t), options...))
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewHashServiceClient(conn)
+}
+
+// encoded returns the request form of msg, given by type name and bytes.
+func encoded(t *testing.T, msg proto.Message) *pb.Message {
+	t.Helper()
+	value, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &pb.Message{Message: &pb.Message_Encoded{Encoded: &pb.EncodedMessage{
+		TypeName: string(msg.ProtoReflect().Descriptor().FullName()),
+		Value:    value,
+	}}}
+}
+
+// packed returns the request form of msg, packed in an Any.
+func packed(t *testing.T, msg proto.Message) *pb.Message {
+	t.Helper()
+	any, err := anypb.New(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &pb.Message{Message: &pb.Message_Any{Any: any}}
+}
+
+func person() *pb3_latest.PersonV4 {
+	return &pb3_latest.PersonV4{
+		Id:             1,
+		StructuredName: &pb3_latest.PersonV4_NameV4{First: "Ada", Last: "Lovelace"},
+		Children:       []*pb3_latest.PersonV3{{Id: 2}},
+	}
+}
+
+func TestHash(t *testing.T) {
+	packedSimple, err := anypb.New(&pb3_latest.Simple{StringField: "packed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := &pb3_latest.KnownTypes{AnyField: packedSimple}
+
+	for name, options := range map[string][]protoreflecthash.Option{
+		"default":          nil,
+		"field names":      {protoreflecthash.FieldNamesAsKeys()},
+		"fullname and sha": {protoreflecthash.MessageFullnameIdentifier(), protoreflecthash.SHA384()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			h := protoreflecthash.NewHasher(options...)
+			hash, err := h.HashProto(msg.ProtoReflect())
+			if err != nil {
+				t.Fatal(err)
+			}
+			tree, err := h.(protoreflecthash.TreeProtoHasher).HashTree(msg.ProtoReflect())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			client := newClient(t, options...)
+			for _, req := range []*pb.HashRequest{
+				{Message: encoded(t, msg)},
+				{Message: packed(t, msg)},
+				{Message: encoded(t, msg), Tree: true},
+				{Message: packed(t, msg), Tree: true},
+			} {
+				got, err := client.Hash(context.Background(), req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want := &pb.HashResponse{TypeName: "schema.proto3.KnownTypes", Hash: hash}
+				if req.Tree {
+					want.Tree = nodeProto(tree)
+				}
+				if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+					t.Errorf("response mismatch (-want +got):\n%s", diff)
+				}
+			}
+		})
+	}
+}
+
+func TestHashBatch(t *testing.T) {
+	client := newClient(t)
+	hash, err := protoreflecthash.NewHasher().HashProto(person().ProtoReflect())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := client.HashBatch(context.Background(), &pb.HashBatchRequest{Messages: []*pb.Message{
+		encoded(t, person()),
+		{Message: &pb.Message_Encoded{Encoded: &pb.EncodedMessage{TypeName: "schema.proto3.Nope"}}},
+		packed(t, person()),
+		{},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &pb.HashBatchResponse{Results: []*pb.HashResult{
+		{Result: &pb.HashResult_Hash{Hash: hash}},
+		{Result: &pb.HashResult_Error{Error: `unknown message type "schema.proto3.Nope"`}},
+		{Result: &pb.HashResult_Hash{Hash: hash}},
+		{Result: &pb.HashResult_Error{Error: "message not set"}},
+	}}
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Errorf("response mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	changed := person()
+	changed.StructuredName.First = "Augusta"
+	changed.Profession = "mathematician"
+
+	got, err := newClient(t).Diff(context.Background(), &pb.DiffRequest{
+		A: encoded(t, person()),
+		B: packed(t, changed),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &pb.DiffResponse{
+		Paths:     []string{"structured_name.first", "profession"},
+		FieldMask: &fieldmaskpb.FieldMask{Paths: []string{"profession", "structured_name.first"}},
+	}
+	if diff := cmp.Diff(want, got, protocmp.Transform()); diff != "" {
+		t.Errorf("response mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestProve(t *testing.T) {
+	client := newClient(t)
+	msg := person()
+
+	got, err := client.Prove(context.Background(), &pb.ProveRequest{Message: encoded(t, msg), Path: "structured_name.first"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, err := protoreflecthash.VerifyProof(got.Hash, "structured_name.first", protoreflect.ValueOfString("Ada"), proofFromProto(got.Proof))
+	if err != nil || !ok {
+		t.Errorf("proof of presence does not verify: %v", err)
+	}
+	ok, err = protoreflecthash.VerifyProof(got.Hash, "structured_name.first", protoreflect.ValueOfString("Augusta"), proofFromProto(got.Proof))
+	if err != nil || ok {
+		t.Errorf("proof of presence verifies another value: %v", err)
+	}
+
+	got, err = client.Prove(context.Background(), &pb.ProveRequest{Message: packed(t, msg), Path: "profession", Absence: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok, err = protoreflecthash.VerifyAbsenceProof(got.Hash, "profession", proofFromProto(got.Proof))
+	if err != nil || !ok {
+		t.Errorf("proof of absence does not verify: %v", err)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	client := newClient(t)
+	ctx := context.Background()
+
+	for name, tc := range map[string]struct {
+		call func() error
+		code codes.Code
+		msg  string
+	}{
+		"message not set": {
+			call: func() error { _, err := client.Hash(ctx, &pb.HashRequest{}); return err },
+			code: codes.InvalidArgument,
+			msg:  "message not set",
+		},
+		"unknown type": {
+			call: func() error {
+				_, err := client.Hash(ctx, &pb.HashRequest{Message: &pb.Message{Message: &pb.Message_Any{Any: &anypb.Any{TypeUrl: "type.googleapis.com/schema.proto3.Nope"}}}})
+				return err
+			},
+			code: codes.NotFound,
+			msg:  `unknown message type "type.googleapis.com/schema.proto3.Nope"`,
+		},
+		"not a message type": {
+			call: func() error {
+				_, err := client.Hash(ctx, &pb.HashRequest{Message: &pb.Message{Message: &pb.Message_Encoded{Encoded: &pb.EncodedMessage{TypeName: "schema.proto3.Simple.bool_field"}}}})
+				return err
+			},
+			code: codes.InvalidArgument,
+			msg:  "schema.proto3.Simple.bool_field is not a message type",
+		},
+		"invalid message": {
+			call: func() error {
+				_, err := client.Hash(ctx, &pb.HashRequest{Message: &pb.Message{Message: &pb.Message_Encoded{Encoded: &pb.EncodedMessage{TypeName: "schema.proto2.BadWithRequirements"}}}})
+				return err
+			},
+			code: codes.InvalidArgument,
+			msg:  "invalid message: required field schema.proto2.BadWithRequirements.text not set",
+		},
+		"different types": {
+			call: func() error {
+				_, err := client.Diff(ctx, &pb.DiffRequest{A: encoded(t, person()), B: encoded(t, &pb3_latest.Simple{})})
+				return err
+			},
+			code: codes.InvalidArgument,
+			msg:  "cannot diff schema.proto3.PersonV4 against schema.proto3.Simple",
+		},
+		"path not in message": {
+			call: func() error {
+				_, err := client.Prove(ctx, &pb.ProveRequest{Message: encoded(t, person()), Path: "profession"})
+				return err
+			},
+			code: codes.InvalidArgument,
+		},
+	} {
+		st := status.Convert(tc.call())
+		if st.Code() != tc.code {
+			t.Errorf("%s: want code %v, got %v (%s)", name, tc.code, st.Code(), st.Message())
+		}
+		if tc.msg != "" && st.Message() != tc.msg {
+			t.Errorf("%s: want message %q, got %q", name, tc.msg, st.Message())
+		}
+	}
+}
+
+// proofFromProto converts a proof from its message form.
+func proofFromProto(m *pb.Proof) *protoreflecthash.Proof {
+	proof := &protoreflecthash.Proof{}
+	for _, step := range m.GetSteps() {
+		proof.Steps = append(proof.Steps, protoreflecthash.ProofStep{
+			Identifier:  step.GetIdentifier(),
+			FieldNumber: step.GetFieldNumber(),
+			Siblings:    step.GetSiblings(),
+			Index:       int(step.GetIndex()),
+		})
+	}
+	return proof
+}
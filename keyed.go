@@ -0,0 +1,155 @@
+package protoreflecthash
+
+import (
+	"crypto/hmac"
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ErrNoKey is returned by HashProtoKeyed when the hasher was not created with
+// the HMAC option.
+var ErrNoKey = errors.New("hasher has no HMAC key")
+
+// KeyScope determines which node hashes are computed with HMAC by a keyed
+// hasher.
+type KeyScope int
+
+const (
+	// KeyRoot keys only the hash of the root node.  The hashes of all other
+	// nodes are the same as those computed by an unkeyed hasher.
+	KeyRoot KeyScope = iota
+	// KeyAllNodes keys the hash of every node, so that the hash of no subtree
+	// can be confirmed without the key.
+	KeyAllNodes
+)
+
+// String returns the name of the scope.
+func (s KeyScope) String() string {
+	switch s {
+	case KeyRoot:
+		return "root"
+	case KeyAllNodes:
+		return "all"
+	}
+	return fmt.Sprintf("KeyScope(%d)", int(s))
+}
+
+// HMAC is an option that computes hashes with HMAC under the given key, using
+// the hash function selected by HashFunc (SHA-256 by default).  Keyed nodes
+// are hashed with the same type identifiers and pre-images as unkeyed ones, so
+// keyed and unkeyed hash trees are structurally the same.  The key identifier
+// is recorded in the KeyedHash returned by HashProtoKeyed so that a verifier
+// can select the right key.
+func HMAC(keyID string, key []byte, scope KeyScope) Option {
+	return func(h *hasher) {
+		h.keyID = keyID
+		h.key = key
+		h.keyScope = scope
+		h.digest.key = nil
+		if scope == KeyAllNodes {
+			h.digest.key = key
+		}
+	}
+}
+
+// KeyedHash is a hash computed under an HMAC key.
+type KeyedHash struct {
+	// KeyID identifies the key the hash was computed with.
+	KeyID string
+	// Scope records which nodes were hashed with the key.
+	Scope KeyScope
+	// Hash is the keyed hash.
+	Hash []byte
+}
+
+// String returns the key identifier, scope and hex-encoded hash, separated by
+// colons.
+func (k *KeyedHash) String() string {
+	return fmt.Sprintf("%s:%s:%x", k.KeyID, k.Scope, k.Hash)
+}
+
+// KeyedProtoHasher is implemented by the ProtoHasher returned by NewHasher.
+type KeyedProtoHasher interface {
+	ProtoHasher
+	// HashProtoKeyed returns the keyed hash of the given message, together with
+	// the identifier of the key.  It fails with ErrNoKey if the hasher was not
+	// created with the HMAC option.
+	HashProtoKeyed(msg protoreflect.Message) (*KeyedHash, error)
+}
+
+// HashProtoKeyed implements KeyedProtoHasher.
+func (h *hasher) HashProtoKeyed(msg protoreflect.Message) (*KeyedHash, error) {
+	if h.key == nil {
+		return nil, ErrNoKey
+	}
+	hash, err := h.HashProto(msg)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyedHash{KeyID: h.keyID, Scope: h.keyScope, Hash: hash}, nil
+}
+
+// VerifyKeyedHash reports whether keyed is the hash of msg, using the key
+// identified by keyed.KeyID in keys.  The options must be those the hash was
+// computed with, other than HMAC.
+func VerifyKeyedHash(msg protoreflect.Message, keyed *KeyedHash, keys map[string][]byte, options ...Option) (bool, error) {
+	key, ok := keys[keyed.KeyID]
+	if !ok {
+		return false, fmt.Errorf("unknown HMAC key %q", keyed.KeyID)
+	}
+
+	h := NewHasher(append(options, HMAC(keyed.KeyID, key, keyed.Scope))...)
+	hash, err := h.HashProto(msg)
+	if err != nil {
+		return false, err
+	}
+
+	return hmac.Equal(hash, keyed.Hash), nil
+}
+
+// splitRoot returns the hasher of the node of the message being hashed and the
+// hasher of its children.  They are both h, unless the message is the root and
+// only the root is keyed, in which case only the node is hashed under the key.
+func (h *hasher) splitRoot() (*hasher, *hasher) {
+	if h.rootKey == nil {
+		return h, h
+	}
+	children := *h
+	children.rootKey = nil
+	node := children
+	node.digest = h.digest.withKey(h.rootKey)
+	return &node, &children
+}
+
+// hashWellKnownRoot computes the hash of a message of a well-known type, which
+// may be the root.  As well-known types build their nodes themselves, the
+// identifier and pre-image of the root node, the last one hashed, are captured
+// and hashed again under the key.
+func (h *hasher) hashWellKnownRoot(wkt func(*hasher, protoreflect.MessageDescriptor, protoreflect.Message) ([]byte, error), md protoreflect.MessageDescriptor, msg protoreflect.Message) ([]byte, error) {
+	if h.rootKey == nil {
+		return h.hashWellKnownType(wkt, md, msg)
+	}
+
+	var t string
+	var b []byte
+
+	unkeyed := *h
+	unkeyed.rootKey = nil
+	observe := h.digest.observe
+	unkeyed.digest.observe = func(nt string, nb []byte, sum []byte) {
+		t, b = nt, nb
+		if observe != nil {
+			observe(nt, nb, sum)
+		}
+	}
+
+	if _, err := unkeyed.hashWellKnownType(wkt, md, msg); err != nil {
+		return nil, err
+	}
+
+	keyed := h.digest.withKey(h.rootKey)
+	keyed.observe = nil
+	return keyed.hash(t, b)
+}
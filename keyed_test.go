@@ -0,0 +1,161 @@
+package protoreflecthash
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	pb3_latest "github.com/stackb/protoreflecthash/test_protos/generated/latest/proto3"
+)
+
+func TestHashHMAC(t *testing.T) {
+	key := []byte("tenant-a")
+
+	mac := func(key []byte, parts ...[]byte) []byte {
+		m := hmac.New(sha256.New, key)
+		for _, part := range parts {
+			m.Write(part)
+		}
+		return m.Sum(nil)
+	}
+	plain := func(parts ...[]byte) []byte {
+		h := sha256.New()
+		for _, part := range parts {
+			h.Write(part)
+		}
+		return h.Sum(nil)
+	}
+	join := func(parts ...[]byte) []byte {
+		var b []byte
+		for _, part := range parts {
+			b = append(b, part...)
+		}
+		return b
+	}
+
+	for name, tc := range map[string]struct {
+		scope KeyScope
+		msg   proto.Message
+		want  []byte
+	}{
+		"root (single node)": {
+			scope: KeyRoot,
+			msg:   &wrapperspb.StringValue{Value: "foo"},
+			want:  mac(key, []byte("ufoo")),
+		},
+		"root": {
+			scope: KeyRoot,
+			msg:   &pb3_latest.KnownTypes{StringValueField: &wrapperspb.StringValue{Value: "foo"}},
+			want:  mac(key, []byte("d"), join(plain([]byte("i10")), plain([]byte("ufoo")))),
+		},
+		"all nodes (single node)": {
+			scope: KeyAllNodes,
+			msg:   &wrapperspb.StringValue{Value: "foo"},
+			want:  mac(key, []byte("ufoo")),
+		},
+		"all nodes": {
+			scope: KeyAllNodes,
+			msg:   &pb3_latest.KnownTypes{StringValueField: &wrapperspb.StringValue{Value: "foo"}},
+			want:  mac(key, []byte("d"), join(mac(key, []byte("i10")), mac(key, []byte("ufoo")))),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			h := NewHasher(HMAC("a", key, tc.scope))
+
+			got := getHash(t, func() ([]byte, error) {
+				return h.HashProto(tc.msg.ProtoReflect())
+			})
+
+			if diff := cmp.Diff(fmt.Sprintf("%x", tc.want), got); diff != "" {
+				t.Errorf("protohash (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestHashProtoKeyed(t *testing.T) {
+	msg := &pb3_latest.Simple{StringField: "foo"}
+	keys := map[string][]byte{
+		"a": []byte("tenant-a"),
+		"b": []byte("tenant-b"),
+	}
+
+	if _, err := NewHasher().(KeyedProtoHasher).HashProtoKeyed(msg.ProtoReflect()); !errors.Is(err, ErrNoKey) {
+		t.Fatalf("want error %v, got %v", ErrNoKey, err)
+	}
+
+	for _, scope := range []KeyScope{KeyRoot, KeyAllNodes} {
+		t.Run(scope.String(), func(t *testing.T) {
+			ha := NewHasher(FieldNamesAsKeys(), HMAC("a", keys["a"], scope)).(KeyedProtoHasher)
+			hb := NewHasher(FieldNamesAsKeys(), HMAC("b", keys["b"], scope)).(KeyedProtoHasher)
+
+			keyedA, err := ha.HashProtoKeyed(msg.ProtoReflect())
+			if err != nil {
+				t.Fatal(err)
+			}
+			keyedB, err := hb.HashProtoKeyed(msg.ProtoReflect())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if keyedA.KeyID != "a" || keyedA.Scope != scope {
+				t.Errorf("unexpected key identifier and scope: %v", keyedA)
+			}
+			if hmac.Equal(keyedA.Hash, keyedB.Hash) {
+				t.Error("hashes under different keys are equal")
+			}
+
+			for _, keyed := range []*KeyedHash{keyedA, keyedB} {
+				ok, err := VerifyKeyedHash(msg.ProtoReflect(), keyed, keys, FieldNamesAsKeys())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !ok {
+					t.Errorf("%v: want verified", keyed)
+				}
+			}
+
+			other := &pb3_latest.Simple{StringField: "bar"}
+			if ok, err := VerifyKeyedHash(other.ProtoReflect(), keyedA, keys, FieldNamesAsKeys()); err != nil || ok {
+				t.Errorf("want unverified hash of different message, got %v (%v)", ok, err)
+			}
+
+			if _, err := VerifyKeyedHash(msg.ProtoReflect(), &KeyedHash{KeyID: "c", Scope: scope, Hash: keyedA.Hash}, keys); err == nil {
+				t.Error("want error for unknown key")
+			}
+		})
+	}
+}
+
+func TestHashHMACRootAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("hash states are not reliably reused with the race detector")
+	}
+	h := NewHasher(HMAC("a", []byte("tenant-a"), KeyRoot)).(AppendingProtoHasher)
+	buf := make([]byte, 0, 64)
+	allocs := func(msg proto.Message) float64 {
+		return testing.AllocsPerRun(100, func() {
+			var err error
+			if buf, err = h.AppendHash(buf[:0], msg.ProtoReflect()); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	// Only the root node is keyed, so the other nodes are hashed as without a
+	// key, without being observed.
+	one := allocs(&pb3_latest.Repetitive{Int32Field: []int32{1}})
+	many := &pb3_latest.Repetitive{}
+	for i := 0; i < 100; i++ {
+		many.Int32Field = append(many.Int32Field, int32(i))
+	}
+	if got := allocs(many); got != one {
+		t.Errorf("want %v allocations regardless of the number of elements, got %v", one, got)
+	}
+}
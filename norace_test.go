@@ -0,0 +1,8 @@
+//go:build !race
+// +build !race
+
+package protoreflecthash
+
+// raceEnabled reports whether the race detector is enabled, which makes
+// sync.Pool drop items at random.
+const raceEnabled = false
@@ -0,0 +1,155 @@
+package protoreflecthash
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Parallel is an option that hashes the elements of lists and the entries of
+// maps having at least threshold of them concurrently.  The calling goroutine
+// is helped by up to workers-1 others, shared by all the lists and maps being
+// hashed by the hasher, so that nested lists do not multiply them.  The hashes
+// are the same as those computed sequentially.  Operations tracking paths or
+// observing nodes, such as HashTree, always hash sequentially.
+func Parallel(workers, threshold int) Option {
+	return func(h *hasher) {
+		h.parallelism = nil
+		if workers > 1 {
+			h.parallelism = &parallelism{
+				threshold: threshold,
+				workers:   make(chan struct{}, workers-1),
+			}
+		}
+	}
+}
+
+// parallelism bounds the goroutines hashing lists and maps concurrently.
+type parallelism struct {
+	// threshold is the size from which lists and maps are hashed concurrently.
+	threshold int
+	// workers holds a token for each goroutine helping the callers.
+	workers chan struct{}
+}
+
+// forEach calls f for each index in [0, n), from the calling goroutine and as
+// many available workers as are useful.
+func (p *parallelism) forEach(n int, f func(i int)) {
+	next := int64(-1)
+	work := func() {
+		for {
+			i := int(atomic.AddInt64(&next, 1))
+			if i >= n {
+				return
+			}
+			f(i)
+		}
+	}
+
+	var wg sync.WaitGroup
+acquire:
+	for started := 1; started < n; started++ {
+		select {
+		case p.workers <- struct{}{}:
+			wg.Add(1)
+			go func() {
+				defer func() { <-p.workers }()
+				defer wg.Done()
+				work()
+			}()
+		default:
+			break acquire
+		}
+	}
+	work()
+	wg.Wait()
+}
+
+// firstError records the error of the lowest index among those reported
+// concurrently, so that the error returned does not depend on scheduling.
+type firstError struct {
+	mu    sync.Mutex
+	index int
+	err   error
+}
+
+func (e *firstError) report(i int, err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.err == nil || i < e.index {
+		e.index, e.err = i, err
+	}
+}
+
+// isParallel reports whether a list or map of the given size is hashed
+// concurrently.
+func (h *hasher) isParallel(size int) bool {
+	return h.parallelism != nil && size >= h.parallelism.threshold && size > 1 &&
+		h.visitor == nil && h.digest.observe == nil
+}
+
+// appendListParallel appends to dst the hash of a list, hashing its elements
+// concurrently.
+func (h *hasher) appendListParallel(dst []byte, kind protoreflect.Kind, list protoreflect.List) ([]byte, error) {
+	w := h.digest.newNode(listIdentifier)
+	size := w.st.hash.Size()
+
+	n := list.Len()
+	hashes := make([]byte, n*size)
+	var first firstError
+	h.parallelism.forEach(n, func(i int) {
+		// The hash of each element is written in place.
+		if _, err := h.appendValue(hashes[i*size:i*size:(i+1)*size], kind, list.Get(i)); err != nil {
+			first.report(i, err)
+		}
+	})
+	if first.err != nil {
+		w.release()
+		return nil, fmt.Errorf("hashing list item %d: %w", first.index, first.err)
+	}
+
+	w.write(hashes)
+	return w.appendSum(dst), nil
+}
+
+// appendMapParallel appends to dst the hash of a map, hashing its entries
+// concurrently.
+func (h *hasher) appendMapParallel(dst []byte, kd, fd protoreflect.FieldDescriptor, m protoreflect.Map) ([]byte, error) {
+	w := h.digest.newNode(mapIdentifier)
+	size := w.st.hash.Size()
+
+	type mapEntry struct {
+		key   protoreflect.MapKey
+		value protoreflect.Value
+	}
+	entries := make([]mapEntry, 0, m.Len())
+	m.Range(func(mk protoreflect.MapKey, v protoreflect.Value) bool {
+		entries = append(entries, mapEntry{key: mk, value: v})
+		return true
+	})
+
+	sorter := &entrySorter{buf: make([]byte, 2*size*len(entries)), size: size}
+	var first firstError
+	h.parallelism.forEach(len(entries), func(i int) {
+		// The key and value hashes of each entry are written in place.
+		entry := sorter.entry(i)
+		if _, err := h.appendFieldValue(entry[:0:size], kd, entries[i].key.Value()); err != nil {
+			first.report(i, err)
+			return
+		}
+		if _, err := h.appendFieldValue(entry[size:size:2*size], fd, entries[i].value); err != nil {
+			first.report(i, err)
+		}
+	})
+	if first.err != nil {
+		w.release()
+		return nil, fmt.Errorf("hashing map key %v: %w", entries[first.index].key, first.err)
+	}
+
+	sort.Sort(sorter)
+	w.write(sorter.buf)
+	return w.appendSum(dst), nil
+}
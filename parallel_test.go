@@ -0,0 +1,159 @@
+package protoreflecthash
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	pb3_latest "github.com/stackb/protoreflecthash/test_protos/generated/latest/proto3"
+)
+
+func TestParallel(t *testing.T) {
+	person := &pb3_latest.PersonV4{Id: 1}
+	for i := 0; i < 50; i++ {
+		child := &pb3_latest.PersonV3{Id: int32(i), Name: &pb3_latest.PersonV3_FullName{FullName: fmt.Sprint(i)}}
+		for j := 0; j < 10; j++ {
+			child.Children = append(child.Children, &pb3_latest.PersonV3{Id: int32(j)})
+		}
+		person.Children = append(person.Children, child)
+	}
+	repetitive := &pb3_latest.Repetitive{}
+	maps := &pb3_latest.StringMaps{StringToString: map[string]string{}, StringToDouble: map[string]float64{}}
+	for i := 0; i < 1000; i++ {
+		repetitive.Int32Field = append(repetitive.Int32Field, int32(i))
+		repetitive.StringField = append(repetitive.StringField, fmt.Sprint(i%10))
+		maps.StringToString[fmt.Sprint(i)] = fmt.Sprint(i % 7)
+		maps.StringToDouble[fmt.Sprint(i)] = float64(i) / 7
+	}
+
+	for name, options := range map[string][]Option{
+		"default":             nil,
+		"field names as keys": {FieldNamesAsKeys()},
+		"hmac all nodes":      {HMAC("k", []byte("key"), KeyAllNodes)},
+		"hmac root":           {HMAC("k", []byte("key"), KeyRoot)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			sequential := NewHasher(options...).(TreeProtoHasher)
+			parallel := NewHasher(append(options, Parallel(4, 8))...).(TreeProtoHasher)
+
+			for _, msg := range []proto.Message{person, repetitive, maps, &pb3_latest.Repetitive{Int32Field: []int32{1, 2}}} {
+				want, err := sequential.HashProto(msg.ProtoReflect())
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := parallel.HashProto(msg.ProtoReflect())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(want, got) {
+					t.Errorf("%T: want hash %x, got %x", msg, want, got)
+				}
+			}
+
+			// Hash trees are built sequentially.
+			msg := &pb3_latest.Repetitive{Int32Field: []int32{1, 2, 3}, StringField: []string{"a", "b", "c"}}
+			wantTree, err := sequential.HashTree(msg.ProtoReflect())
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotTree, err := parallel.HashTree(msg.ProtoReflect())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(wantTree, gotTree); diff != "" {
+				t.Errorf("tree (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestParallelErrors(t *testing.T) {
+	md := valuesDescriptor(t)
+	msg := dynamicpb.NewMessage(md)
+	values := msg.Mutable(md.Fields().ByName("values")).List()
+	entries := msg.Mutable(md.Fields().ByName("entries")).Map()
+	for i := 0; i < 100; i++ {
+		// A Value having no kind cannot be hashed.
+		value := structpb.NewNumberValue(float64(i))
+		if i == 30 || i == 70 {
+			value = &structpb.Value{}
+		}
+		values.Append(protoreflect.ValueOfMessage(value.ProtoReflect()))
+	}
+
+	sequential := NewHasher()
+	_, want := sequential.HashProto(msg)
+	if want == nil {
+		t.Fatal("expected an error")
+	}
+	for i := 0; i < 10; i++ {
+		_, got := NewHasher(Parallel(8, 2)).HashProto(msg)
+		if got == nil || got.Error() != want.Error() {
+			t.Fatalf("want error %v, got %v", want, got)
+		}
+	}
+
+	values.Truncate(0)
+	entries.Set(protoreflect.ValueOfString("a").MapKey(), protoreflect.ValueOfMessage(structpb.NewBoolValue(true).ProtoReflect()))
+	entries.Set(protoreflect.ValueOfString("b").MapKey(), protoreflect.ValueOfMessage((&structpb.Value{}).ProtoReflect()))
+	_, err := NewHasher(Parallel(8, 2)).HashProto(msg)
+	if err == nil || !strings.Contains(err.Error(), `hashing map key b`) {
+		t.Errorf("want error hashing map key b, got %v", err)
+	}
+}
+
+// valuesDescriptor returns the descriptor of a message having a list and a map
+// of google.protobuf.Value.
+func valuesDescriptor(t *testing.T) protoreflect.MessageDescriptor {
+	value := func(name string, number int32, label descriptorpb.FieldDescriptorProto_Label, typeName string) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			JsonName: proto.String(name),
+			Number:   proto.Int32(number),
+			Label:    label.Enum(),
+			Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+			TypeName: proto.String(typeName),
+		}
+	}
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("parallel_test.proto"),
+		Package:    proto.String("parallel"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/struct.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Values"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				value("values", 1, descriptorpb.FieldDescriptorProto_LABEL_REPEATED, ".google.protobuf.Value"),
+				value("entries", 2, descriptorpb.FieldDescriptorProto_LABEL_REPEATED, ".parallel.Values.EntriesEntry"),
+			},
+			NestedType: []*descriptorpb.DescriptorProto{{
+				Name: proto.String("EntriesEntry"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					{
+						Name:     proto.String("key"),
+						JsonName: proto.String("key"),
+						Number:   proto.Int32(1),
+						Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						Type:     descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+					},
+					value("value", 2, descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL, ".google.protobuf.Value"),
+				},
+				Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+			}},
+		}},
+	}, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return file.Messages().ByName("Values")
+}
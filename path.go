@@ -0,0 +1,392 @@
+package protoreflecthash
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Path identifies a value within a message as a sequence of steps, each being
+// a field, a list element or a map entry.  Its string form uses dots to
+// separate field names and brackets for list indices and map keys, for
+// example `orders[3].amount` or `labels["env"]`.  Extension fields are named
+// by their full name in parentheses, as in `(example.ext).value`.
+type Path []PathStep
+
+// PathStep is a single step of a Path.
+type PathStep struct {
+	// Field is the name of the field stepped into, for field steps.  For
+	// extension fields, it is the full name in parentheses.
+	Field string
+	// Key is the list index or map key stepped into, for list element and map
+	// entry steps.  It is an int64 for list indices and integer map keys, a
+	// uint64 only for unsigned integer map keys too large for an int64, a
+	// string for string map keys and a bool for bool map keys.  This is the
+	// same representation ParsePath produces, so parsed and visited paths
+	// compare equal.
+	Key interface{}
+}
+
+// ParsePath parses the string form of a path.
+func ParsePath(s string) (Path, error) {
+	var path Path
+	rest := s
+	for len(rest) > 0 {
+		switch {
+		case rest[0] == '[':
+			end, key, err := parsePathKey(rest)
+			if err != nil {
+				return nil, fmt.Errorf("parsing path %q: %w", s, err)
+			}
+			path = append(path, PathStep{Key: key})
+			rest = rest[end:]
+		case rest[0] == '.' && len(path) > 0:
+			rest = rest[1:]
+			if len(rest) == 0 || rest[0] == '[' || rest[0] == '.' {
+				return nil, fmt.Errorf("parsing path %q: expected field name after '.'", s)
+			}
+		case rest[0] == '(':
+			end := strings.IndexByte(rest, ')') + 1
+			if end <= 2 {
+				return nil, fmt.Errorf("parsing path %q: invalid extension name at %q", s, rest)
+			}
+			path = append(path, PathStep{Field: rest[:end]})
+			rest = rest[end:]
+		default:
+			end := 0
+			for end < len(rest) && isPathNameChar(rest[end]) {
+				end++
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("parsing path %q: expected field name at %q", s, rest)
+			}
+			path = append(path, PathStep{Field: rest[:end]})
+			rest = rest[end:]
+		}
+	}
+	return path, nil
+}
+
+// MustParsePath is like ParsePath but panics if the path cannot be parsed.
+func MustParsePath(s string) Path {
+	path, err := ParsePath(s)
+	if err != nil {
+		panic(err)
+	}
+	return path
+}
+
+// parsePathKey parses a bracketed list index or map key at the start of s,
+// returning the length of the bracketed expression and the key.
+func parsePathKey(s string) (int, interface{}, error) {
+	if strings.HasPrefix(s, `["`) {
+		quoted, err := strconv.QuotedPrefix(s[1:])
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid quoted key at %q", s)
+		}
+		end := 1 + len(quoted)
+		if end >= len(s) || s[end] != ']' {
+			return 0, nil, fmt.Errorf("missing ']' at %q", s)
+		}
+		key, err := strconv.Unquote(quoted)
+		if err != nil {
+			return 0, nil, fmt.Errorf("invalid quoted key at %q", s)
+		}
+		return end + 1, key, nil
+	}
+
+	end := strings.IndexByte(s, ']')
+	if end < 0 {
+		return 0, nil, fmt.Errorf("missing ']' at %q", s)
+	}
+	text := s[1:end]
+	switch text {
+	case "true":
+		return end + 1, true, nil
+	case "false":
+		return end + 1, false, nil
+	}
+	if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return end + 1, i, nil
+	}
+	if u, err := strconv.ParseUint(text, 10, 64); err == nil {
+		return end + 1, u, nil
+	}
+	return 0, nil, fmt.Errorf("invalid key %q", text)
+}
+
+func isPathNameChar(c byte) bool {
+	return c == '_' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
+
+// String returns the string form of the path.
+func (p Path) String() string {
+	var sb strings.Builder
+	for i, step := range p {
+		if step.Key == nil {
+			if i > 0 {
+				sb.WriteByte('.')
+			}
+			sb.WriteString(step.Field)
+			continue
+		}
+		sb.WriteByte('[')
+		switch key := step.Key.(type) {
+		case string:
+			sb.WriteString(strconv.Quote(key))
+		default:
+			fmt.Fprint(&sb, key)
+		}
+		sb.WriteByte(']')
+	}
+	return sb.String()
+}
+
+// fieldStep returns the path step for the given field.
+func fieldStep(fd protoreflect.FieldDescriptor) PathStep {
+	if fd.IsExtension() {
+		return PathStep{Field: "(" + string(fd.FullName()) + ")"}
+	}
+	return PathStep{Field: string(fd.Name())}
+}
+
+// fieldByStep returns the field of the message named by the given field step,
+// or nil if there is none.  Extension fields are found among the populated
+// extensions of the message.
+func fieldByStep(msg protoreflect.Message, step PathStep) protoreflect.FieldDescriptor {
+	if !strings.HasPrefix(step.Field, "(") {
+		return msg.Descriptor().Fields().ByName(protoreflect.Name(step.Field))
+	}
+	var found protoreflect.FieldDescriptor
+	msg.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		if fd.IsExtension() && fieldStep(fd).Field == step.Field {
+			found = fd
+		}
+		return found == nil
+	})
+	return found
+}
+
+// resolveFieldStep returns the field of the message named by the given field
+// step, whether or not it is populated.  Extension fields are resolved by name
+// with the type resolver.
+func (h *hasher) resolveFieldStep(msg protoreflect.Message, step PathStep) (protoreflect.FieldDescriptor, error) {
+	md := msg.Descriptor()
+	if !strings.HasPrefix(step.Field, "(") {
+		fd := md.Fields().ByName(protoreflect.Name(step.Field))
+		if fd == nil {
+			return nil, fmt.Errorf("no such field in %s", md.FullName())
+		}
+		return fd, nil
+	}
+
+	name := protoreflect.FullName(strings.TrimSuffix(strings.TrimPrefix(step.Field, "("), ")"))
+	xt, err := h.getExtensionTypeResolver().FindExtensionByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("resolving extension: %w", err)
+	}
+	fd := xt.TypeDescriptor()
+	if fd.ContainingMessage().FullName() != md.FullName() {
+		return nil, fmt.Errorf("extension does not extend %s", md.FullName())
+	}
+	return fd, nil
+}
+
+// indexStep returns the path step for the given list index.
+func indexStep(i int) PathStep {
+	return PathStep{Key: int64(i)}
+}
+
+// mapKeyStep returns the path step for the given map key.  Integer keys are
+// represented as by ParsePath.
+func mapKeyStep(mk protoreflect.MapKey) PathStep {
+	switch key := mk.Interface().(type) {
+	case int32:
+		return PathStep{Key: int64(key)}
+	case uint32:
+		return PathStep{Key: int64(key)}
+	case uint64:
+		if key <= math.MaxInt64 {
+			return PathStep{Key: int64(key)}
+		}
+		return PathStep{Key: key}
+	default:
+		return PathStep{Key: key}
+	}
+}
+
+// mapKeyOf converts the key of a path step to a map key of the given kind.
+func mapKeyOf(kd protoreflect.FieldDescriptor, key interface{}) (protoreflect.MapKey, error) {
+	var value protoreflect.Value
+	switch k := key.(type) {
+	case string:
+		if kd.Kind() == protoreflect.StringKind {
+			value = protoreflect.ValueOfString(k)
+		}
+	case bool:
+		if kd.Kind() == protoreflect.BoolKind {
+			value = protoreflect.ValueOfBool(k)
+		}
+	case int64:
+		value = intMapKeyValue(kd.Kind(), k >= 0, uint64(k), k)
+	case uint64:
+		value = intMapKeyValue(kd.Kind(), true, k, int64(k))
+	}
+	if !value.IsValid() {
+		return protoreflect.MapKey{}, fmt.Errorf("invalid %v map key: %v", kd.Kind(), key)
+	}
+	return value.MapKey(), nil
+}
+
+// intMapKeyValue returns the value of an integer map key of the given kind, or
+// an invalid value if the kind is not an integer kind.
+func intMapKeyValue(kind protoreflect.Kind, nonNegative bool, u uint64, i int64) protoreflect.Value {
+	switch kind {
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		if i == int64(int32(i)) && (i >= 0 || !nonNegative) {
+			return protoreflect.ValueOfInt32(int32(i))
+		}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		if i >= 0 || !nonNegative {
+			return protoreflect.ValueOfInt64(i)
+		}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		if nonNegative && u == uint64(uint32(u)) {
+			return protoreflect.ValueOfUint32(uint32(u))
+		}
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		if nonNegative {
+			return protoreflect.ValueOfUint64(u)
+		}
+	}
+	return protoreflect.Value{}
+}
+
+// pathValue is a value reached by following a path within a message, together
+// with what is needed to replace or clear it in its parent.
+type pathValue struct {
+	// parent is the message holding the field, list or map.
+	parent protoreflect.Message
+	// fd is the field of the parent the value belongs to.
+	fd protoreflect.FieldDescriptor
+	// index is the list index, for list elements.
+	index int
+	// mapKey is the map key, for map entries.
+	mapKey protoreflect.MapKey
+	// step is the final step of the path.
+	step PathStep
+	// value is the value itself.
+	value protoreflect.Value
+}
+
+// lookupPath follows the path within msg.  The path must identify a field,
+// list element or map entry that is present in the message.
+func lookupPath(msg protoreflect.Message, path Path) (*pathValue, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("empty path")
+	}
+
+	var pv *pathValue
+	for i := 0; i < len(path); i++ {
+		step := path[i]
+		if step.Key != nil {
+			return nil, fmt.Errorf("%s: expected field name", path[:i+1])
+		}
+		if msg == nil {
+			return nil, fmt.Errorf("%s: not a message", path[:i])
+		}
+		fd := fieldByStep(msg, step)
+		if fd == nil || !msg.Has(fd) {
+			return nil, fmt.Errorf("%s: field not set in %s", path[:i+1], msg.Descriptor().FullName())
+		}
+		pv = &pathValue{parent: msg, fd: fd, step: step, value: msg.Get(fd)}
+
+		if (fd.IsList() || fd.IsMap()) && i+1 < len(path) {
+			i++
+			step := path[i]
+			if step.Key == nil {
+				return nil, fmt.Errorf("%s: expected list index or map key", path[:i+1])
+			}
+			if fd.IsList() {
+				index, ok := step.Key.(int64)
+				list := pv.value.List()
+				if !ok || index < 0 || index >= int64(list.Len()) {
+					return nil, fmt.Errorf("%s: list index out of range", path[:i+1])
+				}
+				pv = &pathValue{parent: msg, fd: fd, index: int(index), step: step, value: list.Get(int(index))}
+			} else {
+				mk, err := mapKeyOf(fd.MapKey(), step.Key)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %w", path[:i+1], err)
+				}
+				m := pv.value.Map()
+				if !m.Has(mk) {
+					return nil, fmt.Errorf("%s: map key not present", path[:i+1])
+				}
+				pv = &pathValue{parent: msg, fd: fd, mapKey: mk, step: step, value: m.Get(mk)}
+			}
+		}
+
+		msg = nil
+		if i+1 < len(path) && isMessageValue(pv) {
+			// Well-known types are hashed as leaves.
+			if next := pv.value.Message(); wellKnownTypeHashFunc(next.Descriptor().FullName()) == nil {
+				msg = next
+			}
+		}
+	}
+
+	return pv, nil
+}
+
+// Lookup returns the value at the path within msg, together with the field it
+// belongs to.  The path must identify a field, list element or map entry that
+// is present in the message; well-known types are not descended into.  When
+// the path ends with a list index or map key, the value is the element or the
+// entry value of the list or map field returned.
+func (p Path) Lookup(msg protoreflect.Message) (protoreflect.Value, protoreflect.FieldDescriptor, error) {
+	pv, err := lookupPath(msg, p)
+	if err != nil {
+		return protoreflect.Value{}, nil, err
+	}
+	return pv.value, pv.fd, nil
+}
+
+// equal reports whether the paths are the same.
+func (p Path) equal(other Path) bool {
+	if len(p) != len(other) {
+		return false
+	}
+	for i := range p {
+		if p[i] != other[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// hasPrefix reports whether the path starts with the given prefix.
+func (p Path) hasPrefix(prefix Path) bool {
+	return len(p) >= len(prefix) && p[:len(prefix)].equal(prefix)
+}
+
+// isMessageValue reports whether the value of the path value is a message.
+func isMessageValue(pv *pathValue) bool {
+	if pv.fd.IsMap() {
+		if pv.step.Key == nil {
+			return false
+		}
+		return isMessageKind(pv.fd.MapValue().Kind())
+	}
+	if pv.fd.IsList() && pv.step.Key == nil {
+		return false
+	}
+	return isMessageKind(pv.fd.Kind())
+}
+
+func isMessageKind(kind protoreflect.Kind) bool {
+	return kind == protoreflect.MessageKind || kind == protoreflect.GroupKind
+}
@@ -0,0 +1,228 @@
+package protoreflecthash
+
+import (
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	pb3_latest "github.com/stackb/protoreflecthash/test_protos/generated/latest/proto3"
+)
+
+func TestParsePath(t *testing.T) {
+	for name, tc := range map[string]struct {
+		path string
+		want Path
+	}{
+		"field": {
+			path: "name",
+			want: Path{{Field: "name"}},
+		},
+		"nested fields": {
+			path: "structured_name.first",
+			want: Path{{Field: "structured_name"}, {Field: "first"}},
+		},
+		"list index": {
+			path: "children[3].name",
+			want: Path{{Field: "children"}, {Key: int64(3)}, {Field: "name"}},
+		},
+		"string map key": {
+			path: `labels["env \"prod\""]`,
+			want: Path{{Field: "labels"}, {Key: `env "prod"`}},
+		},
+		"negative int map key": {
+			path: "int_to_string[-7]",
+			want: Path{{Field: "int_to_string"}, {Key: int64(-7)}},
+		},
+		"large uint map key": {
+			path: "uint_to_string[18446744073709551615]",
+			want: Path{{Field: "uint_to_string"}, {Key: uint64(18446744073709551615)}},
+		},
+		"bool map key": {
+			path: "bool_to_string[true]",
+			want: Path{{Field: "bool_to_string"}, {Key: true}},
+		},
+		"extension": {
+			path: "(schema.proto2.Extensions.simple_extension).string_field",
+			want: Path{{Field: "(schema.proto2.Extensions.simple_extension)"}, {Field: "string_field"}},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParsePath(tc.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("(-want +got):\n%s", diff)
+			}
+			if got.String() != tc.path {
+				t.Errorf("String: want %q, got %q", tc.path, got.String())
+			}
+		})
+	}
+}
+
+func TestParsePathErrors(t *testing.T) {
+	for name, path := range map[string]string{
+		"leading dot":       ".name",
+		"trailing dot":      "name.",
+		"double dot":        "a..b",
+		"dot before key":    "a.[1]",
+		"unterminated key":  "a[1",
+		"invalid key":       "a[x]",
+		"unterminated name": `a["x]`,
+		"empty extension":   "()",
+		"invalid character": "a-b",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParsePath(path); err == nil {
+				t.Errorf("ParsePath(%q): want error", path)
+			}
+		})
+	}
+}
+
+func TestPathLookup(t *testing.T) {
+	msg := (&pb3_latest.PersonV4{
+		Id:             1,
+		StructuredName: &pb3_latest.PersonV4_NameV4{First: "Ada"},
+		Children:       []*pb3_latest.PersonV3{{Id: 2}, {Id: 3}},
+	}).ProtoReflect()
+	maps := (&pb3_latest.StringMaps{StringToString: map[string]string{"k": "v"}}).ProtoReflect()
+
+	for name, tc := range map[string]struct {
+		msg   protoreflect.Message
+		path  string
+		field string
+		want  interface{}
+	}{
+		"field":      {msg, "id", "id", int32(1)},
+		"nested":     {msg, "structured_name.first", "first", "Ada"},
+		"list":       {msg, "children", "children", 2},
+		"list index": {msg, "children[1].id", "id", int32(3)},
+		"map":        {maps, "string_to_string", "string_to_string", 1},
+		"map entry":  {maps, `string_to_string["k"]`, "string_to_string", "v"},
+	} {
+		path := MustParsePath(tc.path)
+		value, fd, err := path.Lookup(tc.msg)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if got := string(fd.Name()); got != tc.field {
+			t.Errorf("%s: want field %s, got %s", name, tc.field, got)
+		}
+		// Whole lists and maps are compared by length.
+		got := value.Interface()
+		if whole := path[len(path)-1].Key == nil; whole && fd.IsList() {
+			got = value.List().Len()
+		} else if whole && fd.IsMap() {
+			got = value.Map().Len()
+		}
+		if got != tc.want {
+			t.Errorf("%s: want value %v, got %v", name, tc.want, got)
+		}
+	}
+
+	for _, path := range []string{"age", "children[2]", "structured_name.last"} {
+		if _, _, err := MustParsePath(path).Lookup(msg); err == nil {
+			t.Errorf("%s: want error", path)
+		}
+	}
+}
+
+// mapKeyPaths are paths of newMapKeysMessage, one for each of its map entries.
+var mapKeyPaths = []string{
+	"uint32_to_value[5].s",
+	"uint64_to_value[5].s",
+	"uint64_to_value[18446744073709551615].s",
+	"int64_to_value[-7].s",
+	"bool_to_value[true].s",
+}
+
+// newMapKeysMessage returns a message having maps keyed by uint32, uint64,
+// int64 and bool, whose values are messages having a single string field s.
+// The test protos have no maps keyed by unsigned integers.
+func newMapKeysMessage(t *testing.T) protoreflect.Message {
+	mapField := func(name string, number int32, entry string) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			Number:   proto.Int32(number),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+			Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+			TypeName: proto.String(".test.MapKeys." + entry),
+		}
+	}
+	mapEntry := func(name string, keyType descriptorpb.FieldDescriptorProto_Type) *descriptorpb.DescriptorProto {
+		return &descriptorpb.DescriptorProto{
+			Name: proto.String(name),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:   proto.String("key"),
+				Number: proto.Int32(1),
+				Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:   keyType.Enum(),
+			}, {
+				Name:     proto.String("value"),
+				Number:   proto.Int32(2),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+				TypeName: proto.String(".test.MapKeys.Value"),
+			}},
+			Options: &descriptorpb.MessageOptions{MapEntry: proto.Bool(true)},
+		}
+	}
+
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("map_keys.proto"),
+		Package: proto.String("test"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("MapKeys"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				mapField("uint32_to_value", 1, "Uint32ToValueEntry"),
+				mapField("uint64_to_value", 2, "Uint64ToValueEntry"),
+				mapField("int64_to_value", 3, "Int64ToValueEntry"),
+				mapField("bool_to_value", 4, "BoolToValueEntry"),
+			},
+			NestedType: []*descriptorpb.DescriptorProto{
+				{
+					Name: proto.String("Value"),
+					Field: []*descriptorpb.FieldDescriptorProto{{
+						Name:   proto.String("s"),
+						Number: proto.Int32(1),
+						Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+						Type:   descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(),
+					}},
+				},
+				mapEntry("Uint32ToValueEntry", descriptorpb.FieldDescriptorProto_TYPE_UINT32),
+				mapEntry("Uint64ToValueEntry", descriptorpb.FieldDescriptorProto_TYPE_UINT64),
+				mapEntry("Int64ToValueEntry", descriptorpb.FieldDescriptorProto_TYPE_INT64),
+				mapEntry("BoolToValueEntry", descriptorpb.FieldDescriptorProto_TYPE_BOOL),
+			},
+		}},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	md := fd.Messages().Get(0)
+	msg := dynamicpb.NewMessage(md)
+	set := func(field string, key protoreflect.Value, s string) {
+		m := msg.Mutable(md.Fields().ByName(protoreflect.Name(field))).Map()
+		value := m.NewValue()
+		value.Message().Set(value.Message().Descriptor().Fields().ByName("s"), protoreflect.ValueOfString(s))
+		m.Set(key.MapKey(), value)
+	}
+	set("uint32_to_value", protoreflect.ValueOfUint32(5), "uint32")
+	set("uint64_to_value", protoreflect.ValueOfUint64(5), "uint64")
+	set("uint64_to_value", protoreflect.ValueOfUint64(math.MaxUint64), "max uint64")
+	set("int64_to_value", protoreflect.ValueOfInt64(-7), "int64")
+	set("int64_to_value", protoreflect.ValueOfInt64(7), "positive int64")
+	set("bool_to_value", protoreflect.ValueOfBool(true), "bool")
+	return msg
+}
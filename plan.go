@@ -0,0 +1,150 @@
+package protoreflecthash
+
+import (
+	"sort"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// messagePlan holds what hashing the messages of a type requires knowing about
+// the type, so that it is derived once per type rather than for every message.
+// Plans depend on the options of the hasher, so each hasher caches its own.
+type messagePlan struct {
+	// identifier is the type identifier of the messages.
+	identifier string
+	// wellKnown computes the hash of the messages of well-known types having a
+	// canonical hash, and is nil for other types.
+	wellKnown func(*hasher, protoreflect.MessageDescriptor, protoreflect.Message) ([]byte, error)
+	// extendable reports whether the messages may have extension fields.
+	extendable bool
+	// fields are the fields of the type, ordered by number.
+	fields []fieldPlan
+}
+
+// fieldPlan holds what hashing a field requires knowing about it.
+type fieldPlan struct {
+	fd protoreflect.FieldDescriptor
+	// khash is the hash of the key of the field.
+	khash []byte
+	// appendValue appends the hash of a value of the field to dst.
+	appendValue func(h *hasher, dst []byte, fd protoreflect.FieldDescriptor, value protoreflect.Value) ([]byte, error)
+}
+
+// plan returns the plan for hashing messages of the given type, from the cache
+// of the hasher if it has one.
+func (h *hasher) plan(md protoreflect.MessageDescriptor) *messagePlan {
+	if h.plans == nil {
+		return h.newPlan(md)
+	}
+	if plan, ok := h.plans.Load(md); ok {
+		return plan.(*messagePlan)
+	}
+	plan, _ := h.plans.LoadOrStore(md, h.newPlan(md))
+	return plan.(*messagePlan)
+}
+
+func (h *hasher) newPlan(md protoreflect.MessageDescriptor) *messagePlan {
+	plan := &messagePlan{
+		identifier: h.messageIdentifier(md),
+		wellKnown:  wellKnownTypeHashFunc(md.FullName()),
+		extendable: md.ExtensionRanges().Len() > 0,
+	}
+	if plan.wellKnown != nil {
+		return plan
+	}
+
+	// Key hashes are computed out of sight of any observer, as they are not
+	// part of the message being hashed yet.
+	kh := *h
+	kh.digest.observe = nil
+
+	fields := md.Fields()
+	plan.fields = make([]fieldPlan, fields.Len())
+	for i := range plan.fields {
+		fd := fields.Get(i)
+		plan.fields[i] = fieldPlan{
+			fd:          fd,
+			khash:       kh.appendFieldKey(nil, fd),
+			appendValue: fieldValueFunc(fd),
+		}
+	}
+	sort.Slice(plan.fields, func(i, j int) bool {
+		return plan.fields[i].fd.Number() < plan.fields[j].fd.Number()
+	})
+
+	return plan
+}
+
+// fieldKey appends the key hash of the planned field to dst.  The key is
+// hashed again when observed, so that the observer sees it.
+func (h *hasher) fieldKey(dst []byte, fp *fieldPlan) []byte {
+	if h.digest.observe != nil {
+		return h.appendFieldKey(dst, fp.fd)
+	}
+	return append(dst, fp.khash...)
+}
+
+// fieldValueFunc returns the function appending the hash of a value of the
+// given field.
+func fieldValueFunc(fd protoreflect.FieldDescriptor) func(*hasher, []byte, protoreflect.FieldDescriptor, protoreflect.Value) ([]byte, error) {
+	switch {
+	case fd.IsList():
+		return (*hasher).appendListField
+	case fd.IsMap():
+		return (*hasher).appendMapField
+	}
+
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return (*hasher).appendBoolField
+	case protoreflect.EnumKind:
+		return (*hasher).appendEnumField
+	case protoreflect.Uint32Kind, protoreflect.Uint64Kind, protoreflect.Fixed32Kind, protoreflect.Fixed64Kind:
+		return (*hasher).appendUintField
+	case protoreflect.Int32Kind, protoreflect.Int64Kind, protoreflect.Sint32Kind, protoreflect.Sint64Kind, protoreflect.Sfixed32Kind, protoreflect.Sfixed64Kind:
+		return (*hasher).appendIntField
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return (*hasher).appendFloatField
+	case protoreflect.StringKind:
+		return (*hasher).appendStringField
+	case protoreflect.BytesKind:
+		return (*hasher).appendBytesField
+	}
+	return (*hasher).appendFieldValue
+}
+
+func (h *hasher) appendListField(dst []byte, fd protoreflect.FieldDescriptor, value protoreflect.Value) ([]byte, error) {
+	return h.appendList(dst, fd.Kind(), value.List())
+}
+
+func (h *hasher) appendMapField(dst []byte, fd protoreflect.FieldDescriptor, value protoreflect.Value) ([]byte, error) {
+	return h.appendMap(dst, fd.MapKey(), fd.MapValue(), value.Map())
+}
+
+func (h *hasher) appendBoolField(dst []byte, fd protoreflect.FieldDescriptor, value protoreflect.Value) ([]byte, error) {
+	return h.digest.appendBool(dst, value.Bool()), nil
+}
+
+func (h *hasher) appendEnumField(dst []byte, fd protoreflect.FieldDescriptor, value protoreflect.Value) ([]byte, error) {
+	return h.digest.appendInt64(dst, int64(value.Enum())), nil
+}
+
+func (h *hasher) appendUintField(dst []byte, fd protoreflect.FieldDescriptor, value protoreflect.Value) ([]byte, error) {
+	return h.digest.appendUint64(dst, value.Uint()), nil
+}
+
+func (h *hasher) appendIntField(dst []byte, fd protoreflect.FieldDescriptor, value protoreflect.Value) ([]byte, error) {
+	return h.digest.appendInt64(dst, value.Int()), nil
+}
+
+func (h *hasher) appendFloatField(dst []byte, fd protoreflect.FieldDescriptor, value protoreflect.Value) ([]byte, error) {
+	return h.digest.appendFloat(dst, value.Float())
+}
+
+func (h *hasher) appendStringField(dst []byte, fd protoreflect.FieldDescriptor, value protoreflect.Value) ([]byte, error) {
+	return h.digest.appendUnicode(dst, value.String()), nil
+}
+
+func (h *hasher) appendBytesField(dst []byte, fd protoreflect.FieldDescriptor, value protoreflect.Value) ([]byte, error) {
+	return h.digest.appendBytes(dst, value.Bytes()), nil
+}
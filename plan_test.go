@@ -0,0 +1,81 @@
+package protoreflecthash
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb2_latest "github.com/stackb/protoreflecthash/test_protos/generated/latest/proto2"
+	pb3_latest "github.com/stackb/protoreflecthash/test_protos/generated/latest/proto3"
+)
+
+func TestPlanCache(t *testing.T) {
+	st, err := structpb.NewStruct(map[string]interface{}{"a": 1.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs := []proto.Message{
+		&pb3_latest.PersonV4{Id: 1, Children: []*pb3_latest.PersonV3{{Id: 2, Name: &pb3_latest.PersonV3_FullName{FullName: "x"}}}},
+		&pb3_latest.StringMaps{StringToString: map[string]string{"a": "b"}},
+		&pb3_latest.KnownTypes{StructField: st, TimestampField: &timestamppb.Timestamp{Seconds: 1}},
+		&pb2_latest.Simple{Int32Field: proto.Int32(0)},
+	}
+
+	for name, options := range map[string][]Option{
+		"default":             nil,
+		"field names as keys": {FieldNamesAsKeys(), MessageFullnameIdentifier()},
+		"hmac all nodes":      {HMAC("k", []byte("key"), KeyAllNodes)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			// Hashers without a cache derive plans for every message.
+			uncached := NewHasher(options...).(*hasher)
+			uncached.plans = nil
+			var want [][]byte
+			for _, msg := range msgs {
+				hash, err := uncached.HashProto(msg.ProtoReflect())
+				if err != nil {
+					t.Fatal(err)
+				}
+				want = append(want, hash)
+			}
+
+			h := NewHasher(options...).(*hasher)
+			var wg sync.WaitGroup
+			for g := 0; g < 8; g++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for i := 0; i < 10; i++ {
+						for j, msg := range msgs {
+							got, err := h.HashProto(msg.ProtoReflect())
+							if err != nil {
+								t.Error(err)
+								return
+							}
+							if !bytes.Equal(want[j], got) {
+								t.Errorf("%T: want hash %x, got %x", msg, want[j], got)
+							}
+						}
+					}
+				}()
+			}
+			wg.Wait()
+
+			// Nested types are planned along with their parents.
+			for _, md := range []protoreflect.MessageDescriptor{
+				(&pb3_latest.PersonV4{}).ProtoReflect().Descriptor(),
+				(&pb3_latest.PersonV3{}).ProtoReflect().Descriptor(),
+				(&structpb.Struct{}).ProtoReflect().Descriptor(),
+			} {
+				if _, ok := h.plans.Load(md); !ok {
+					t.Errorf("no plan for %s", md.FullName())
+				}
+			}
+		})
+	}
+}
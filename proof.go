@@ -0,0 +1,604 @@
+package protoreflecthash
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"fmt"
+	"sort"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Proof is a Merkle inclusion proof that a value is at a given path within a
+// message having a known hash.  It holds what is needed to recompute the hash
+// of each node along the path from the hash of its child on the path, so that
+// the value can be checked against the root hash without seeing the rest of
+// the message.
+type Proof struct {
+	// Steps holds a step for each step of the path, starting at the root.
+	Steps []ProofStep
+}
+
+// ProofStep holds the contents of a node along the path of a proof, other
+// than the entry for the child on the path.
+type ProofStep struct {
+	// Identifier is the type identifier of the node: "d" for messages (or
+	// their full name, with MessageFullnameIdentifier) and maps, and "l" for
+	// lists.
+	Identifier string
+	// FieldNumber is the number of the field stepped into, for message nodes.
+	FieldNumber int32
+	// Siblings are the entries of the node other than that of the child on the
+	// path, in canonical order.  The entries of messages and maps are the key
+	// hash followed by the value hash; those of lists are the value hash.  In
+	// the last step of a proof of absence, they are all entries of the node.
+	Siblings [][]byte
+	// Index is the position of the entry of the child on the path among its
+	// siblings, or where it would be in a proof of absence.
+	Index int
+}
+
+// ProvingProtoHasher is implemented by the ProtoHasher returned by NewHasher.
+type ProvingProtoHasher interface {
+	ProtoHasher
+	// Prove returns a proof that the value at the given path is part of msg.
+	// The path must identify a field, list element or map entry that is
+	// present in msg; values within well-known types cannot be proven
+	// individually.
+	Prove(msg protoreflect.Message, path string) (*Proof, error)
+	// ProveAbsence returns a proof that the field or map entry at the given
+	// path is absent from msg, although the path leading to it is present.
+	// Fields without presence are absent when they have their default value.
+	ProveAbsence(msg protoreflect.Message, path string) (*Proof, error)
+}
+
+// Prove implements ProvingProtoHasher.
+func (h *hasher) Prove(msg protoreflect.Message, path string) (*Proof, error) {
+	return h.prove(msg, path, false)
+}
+
+// ProveAbsence implements ProvingProtoHasher.  The last step of the proof holds
+// all entries of the node the value is absent from, in canonical order, and
+// the position the entry of the value would have among them.
+func (h *hasher) ProveAbsence(msg protoreflect.Message, path string) (*Proof, error) {
+	return h.prove(msg, path, true)
+}
+
+// prove returns a proof that the value at the given path is present in, or
+// absent from, msg.
+func (h *hasher) prove(msg protoreflect.Message, path string, absent bool) (*Proof, error) {
+	if msg == nil {
+		return nil, fmt.Errorf("cannot prove a path of a nil message")
+	}
+	target, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(target) == 0 {
+		return nil, fmt.Errorf("cannot prove an empty path")
+	}
+
+	// Look up every prefix of the path, both to check that the value is
+	// present and to learn the field numbers of the field steps.
+	numbers := make([]int32, len(target))
+	present := len(target)
+	if absent {
+		present--
+	}
+	for i := 0; i < present; i++ {
+		pv, err := lookupPath(msg, target[:i+1])
+		if err != nil {
+			return nil, fmt.Errorf("proving %s: %w", target, err)
+		}
+		if target[i].Key == nil {
+			numbers[i] = int32(pv.fd.Number())
+		}
+	}
+	var absence *absentValue
+	if absent {
+		if absence, err = h.lookupAbsent(msg, target); err != nil {
+			return nil, fmt.Errorf("proving absence of %s: %w", target, err)
+		}
+		numbers[present] = int32(absence.number)
+	}
+
+	v := &proofVisitor{
+		target:  target,
+		nodes:   make([]proofNode, len(target)),
+		khashes: make([][]byte, len(target)),
+		vhashes: make([][]byte, len(target)),
+	}
+	ph := *h
+	ph.visitor = v
+	ph.digest.observe = v.observe
+	root, err := ph.hashProto(msg)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(v.last.sum, root) {
+		return nil, fmt.Errorf("proving %s: root node not observed", target)
+	}
+	v.nodes[0] = v.last
+
+	proof := &Proof{Steps: make([]ProofStep, len(target))}
+	for i, node := range v.nodes {
+		if node.b == nil && node.t == "" {
+			return nil, fmt.Errorf("proving %s: node %s not observed", target, target[:i])
+		}
+
+		if i == present {
+			step, err := absenceStep(node, len(root), absence)
+			if err != nil {
+				return nil, fmt.Errorf("proving absence of %s: %w", target, err)
+			}
+			step.FieldNumber = numbers[i]
+			proof.Steps[i] = *step
+			continue
+		}
+
+		var entry []byte
+		if node.t == listIdentifier {
+			entry = v.vhashes[i]
+		} else {
+			entry = append(append([]byte(nil), v.khashes[i]...), v.vhashes[i]...)
+		}
+		entries, err := splitEntries(node.b, len(entry))
+		if err != nil {
+			return nil, fmt.Errorf("proving %s: %w", target, err)
+		}
+
+		index := -1
+		if node.t == listIdentifier {
+			index = int(target[i].Key.(int64))
+		} else {
+			for j, e := range entries {
+				if bytes.Equal(e, entry) {
+					index = j
+					break
+				}
+			}
+		}
+		if index < 0 || index >= len(entries) || !bytes.Equal(entries[index], entry) {
+			return nil, fmt.Errorf("proving %s: entry not found in node %s", target, target[:i])
+		}
+
+		siblings := make([][]byte, 0, len(entries)-1)
+		siblings = append(siblings, entries[:index]...)
+		siblings = append(siblings, entries[index+1:]...)
+		proof.Steps[i] = ProofStep{
+			Identifier:  node.t,
+			FieldNumber: numbers[i],
+			Siblings:    siblings,
+			Index:       index,
+		}
+	}
+
+	return proof, nil
+}
+
+// absentValue describes a field or map entry absent from a message.
+type absentValue struct {
+	// number is the number of the absent field, for fields.
+	number protoreflect.FieldNumber
+	// numbers are the numbers of the entries of the message, for fields.
+	numbers []protoreflect.FieldNumber
+	// khash is the hash of the absent map key, for map entries.
+	khash []byte
+}
+
+// lookupAbsent checks that the last step of the path leads to a field or map
+// entry absent from the value the rest of the path leads to.
+func (h *hasher) lookupAbsent(msg protoreflect.Message, target Path) (*absentValue, error) {
+	step := target[len(target)-1]
+	parent := target[:len(target)-1]
+
+	if step.Key != nil {
+		if len(parent) == 0 || parent[len(parent)-1].Key != nil {
+			return nil, fmt.Errorf("expected a map field before the map key")
+		}
+		pv, err := lookupPath(msg, parent)
+		if err != nil {
+			return nil, err
+		}
+		if !pv.fd.IsMap() {
+			return nil, fmt.Errorf("%s is not a map", parent)
+		}
+		mk, err := mapKeyOf(pv.fd.MapKey(), step.Key)
+		if err != nil {
+			return nil, err
+		}
+		if pv.value.Map().Has(mk) {
+			return nil, fmt.Errorf("map key is present")
+		}
+		khash, err := h.hashFieldValue(pv.fd.MapKey(), mk.Value())
+		if err != nil {
+			return nil, err
+		}
+		return &absentValue{khash: khash}, nil
+	}
+
+	if len(parent) > 0 {
+		pv, err := lookupPath(msg, parent)
+		if err != nil {
+			return nil, err
+		}
+		if !isMessageValue(pv) || wellKnownTypeHashFunc(pv.value.Message().Descriptor().FullName()) != nil {
+			return nil, fmt.Errorf("%s is not a message", parent)
+		}
+		msg = pv.value.Message()
+	}
+	fd, err := h.resolveFieldStep(msg, step)
+	if err != nil {
+		return nil, err
+	}
+	if msg.Has(fd) {
+		return nil, fmt.Errorf("field is present")
+	}
+
+	absent := &absentValue{number: fd.Number()}
+	msg.Range(func(fd protoreflect.FieldDescriptor, _ protoreflect.Value) bool {
+		absent.numbers = append(absent.numbers, fd.Number())
+		return true
+	})
+	if h.unknownFieldsMode == UnknownFieldsInclude {
+		unknown, err := rawFieldNumbers(msg.GetUnknown())
+		if err != nil {
+			return nil, err
+		}
+		absent.numbers = append(absent.numbers, unknown...)
+	}
+	return absent, nil
+}
+
+// absenceStep returns the last step of a proof of absence from the given node.
+func absenceStep(node proofNode, size int, absent *absentValue) (*ProofStep, error) {
+	if node.t == listIdentifier {
+		return nil, fmt.Errorf("cannot prove absence from a list")
+	}
+	entries, err := splitEntries(node.b, 2*size)
+	if len(node.b) == 0 {
+		entries, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	index := 0
+	if absent.khash != nil {
+		for index < len(entries) && bytes.Compare(entries[index][:size], absent.khash) < 0 {
+			index++
+		}
+	} else {
+		for _, number := range absent.numbers {
+			if number < absent.number {
+				index++
+			}
+		}
+	}
+	if index > len(entries) {
+		return nil, fmt.Errorf("node has %d entries, but %d precede the value", len(entries), index)
+	}
+
+	return &ProofStep{Identifier: node.t, Siblings: entries, Index: index}, nil
+}
+
+// VerifyProof reports whether the proof shows that leaf is the value at the
+// given path within a message having the given root hash.  The options must be
+// those the root hash was computed with.  No descriptors are needed: the key
+// of each field is taken from the proof (its number) or from the path (its
+// name, with FieldNamesAsKeys), so a verifier keying fields by number must
+// check for itself that the numbers in the proof are those of the named fields.
+func VerifyProof(root []byte, path string, leaf protoreflect.Value, proof *Proof, options ...Option) (bool, error) {
+	target, err := ParsePath(path)
+	if err != nil {
+		return false, err
+	}
+	if len(target) == 0 || len(proof.Steps) != len(target) {
+		return false, fmt.Errorf("proof has %d steps for path %q", len(proof.Steps), path)
+	}
+
+	h := NewHasher(options...).(*hasher)
+	hash, err := h.hashLeafValue(leaf)
+	if err != nil {
+		return false, fmt.Errorf("hashing leaf value: %w", err)
+	}
+
+	if hash, err = h.verifySteps(target, proof.Steps, hash); err != nil {
+		return false, err
+	}
+	return hmac.Equal(hash, root), nil
+}
+
+// VerifyAbsenceProof reports whether the proof shows that the field or map
+// entry at the given path is absent from a message having the given root hash,
+// as VerifyProof does for present values.  The entries of the node the value
+// is absent from are all in the proof, so the key of the value must be among
+// none of them.  The entries of maps must moreover be in canonical order, with
+// the key of the value sorting at the position given by the proof.
+func VerifyAbsenceProof(root []byte, path string, proof *Proof, options ...Option) (bool, error) {
+	target, err := ParsePath(path)
+	if err != nil {
+		return false, err
+	}
+	if len(target) == 0 || len(proof.Steps) != len(target) {
+		return false, fmt.Errorf("proof has %d steps for path %q", len(proof.Steps), path)
+	}
+
+	h := NewHasher(options...).(*hasher)
+	last := len(target) - 1
+	step := proof.Steps[last]
+
+	var khash []byte
+	switch {
+	case target[last].Key == nil:
+		if !h.isMessageIdentifier(step.Identifier) {
+			return false, fmt.Errorf("verifying %s: invalid message identifier %q", target, step.Identifier)
+		}
+		khash, err = h.hashPathFieldKey(target[last], step.FieldNumber)
+	case step.Identifier == mapIdentifier:
+		khash, err = h.hashLeafValue(protoreflect.ValueOf(target[last].Key))
+	default:
+		return false, fmt.Errorf("verifying %s: invalid map identifier %q", target, step.Identifier)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	size := len(khash)
+	if step.Index < 0 || step.Index > len(step.Siblings) {
+		return false, fmt.Errorf("verifying %s: index %d out of range", target, step.Index)
+	}
+	var buf bytes.Buffer
+	for i, sibling := range step.Siblings {
+		if len(sibling) != 2*size {
+			return false, fmt.Errorf("verifying %s: entry %d has %d bytes, want %d", target, i, len(sibling), 2*size)
+		}
+		if bytes.Equal(sibling[:size], khash) {
+			return false, nil
+		}
+		if target[last].Key != nil {
+			// Map entries are sorted by key hash.
+			if i > 0 && bytes.Compare(step.Siblings[i-1][:size], sibling[:size]) >= 0 {
+				return false, nil
+			}
+			if (i < step.Index) != (bytes.Compare(sibling[:size], khash) < 0) {
+				return false, nil
+			}
+		}
+		buf.Write(sibling)
+	}
+
+	d := h.digest
+	if last == 0 && h.key != nil && h.keyScope == KeyRoot {
+		d = d.withKey(h.key)
+	}
+	hash, err := d.hash(step.Identifier, buf.Bytes())
+	if err != nil {
+		return false, err
+	}
+
+	if hash, err = h.verifySteps(target[:last], proof.Steps[:last], hash); err != nil {
+		return false, err
+	}
+	return hmac.Equal(hash, root), nil
+}
+
+// verifySteps computes the root hash from the hash of the value at the given
+// path and the proof steps leading to it.
+func (h *hasher) verifySteps(target Path, steps []ProofStep, hash []byte) ([]byte, error) {
+	for i := len(target) - 1; i >= 0; i-- {
+		step := steps[i]
+		entry, err := h.proofEntry(target[i], step, hash)
+		if err != nil {
+			return nil, fmt.Errorf("verifying %s: %w", target[:i+1], err)
+		}
+
+		b, err := joinEntries(step.Siblings, step.Index, entry)
+		if err != nil {
+			return nil, fmt.Errorf("verifying %s: %w", target[:i+1], err)
+		}
+
+		d := h.digest
+		if i == 0 && h.key != nil && h.keyScope == KeyRoot {
+			d = d.withKey(h.key)
+		}
+		if hash, err = d.hash(step.Identifier, b); err != nil {
+			return nil, err
+		}
+	}
+	return hash, nil
+}
+
+// proofEntry returns the entry of the child reached by the given path step,
+// having the given hash, within the node of the proof step.
+func (h *hasher) proofEntry(ps PathStep, step ProofStep, vhash []byte) ([]byte, error) {
+	var khash []byte
+	var err error
+	switch {
+	case ps.Key == nil:
+		if !h.isMessageIdentifier(step.Identifier) {
+			return nil, fmt.Errorf("invalid message identifier %q", step.Identifier)
+		}
+		khash, err = h.hashPathFieldKey(ps, step.FieldNumber)
+	case step.Identifier == listIdentifier:
+		if index, ok := ps.Key.(int64); !ok || int64(step.Index) != index {
+			return nil, fmt.Errorf("list index %v does not match proof index %d", ps.Key, step.Index)
+		}
+		return vhash, nil
+	case step.Identifier == mapIdentifier:
+		khash, err = h.hashLeafValue(protoreflect.ValueOf(ps.Key))
+	default:
+		return nil, fmt.Errorf("invalid list or map identifier %q", step.Identifier)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte(nil), khash...), vhash...), nil
+}
+
+// isMessageIdentifier reports whether t can be the identifier of a message.
+func (h *hasher) isMessageIdentifier(t string) bool {
+	if !h.messageFullnameIdentifier {
+		return t == mapIdentifier
+	}
+	return t != "" && t != listIdentifier && t != setIdentifier && !isLeafIdentifier(t)
+}
+
+// hashPathFieldKey hashes the key of the field named by the path step, having
+// the given number.
+func (h *hasher) hashPathFieldKey(ps PathStep, number int32) ([]byte, error) {
+	if h.fieldNamesAsKeys {
+		name := ps.Field
+		if len(name) > 2 && name[0] == '(' {
+			name = name[1 : len(name)-1]
+		}
+		return h.digest.hashUnicode(name)
+	}
+	return h.digest.hashInt64(int64(number))
+}
+
+// hashLeafValue hashes a value according to its Go type, without the aid of a
+// field descriptor.  Lists and maps are hashed from their elements.
+func (h *hasher) hashLeafValue(value protoreflect.Value) ([]byte, error) {
+	switch v := value.Interface().(type) {
+	case bool:
+		return h.hashBool(v)
+	case int32:
+		return h.hashInt(int64(v))
+	case int64:
+		return h.hashInt(v)
+	case uint32:
+		return h.hashUint(uint64(v))
+	case uint64:
+		return h.hashUint(v)
+	case float32:
+		return h.hashFloat(float64(v))
+	case float64:
+		return h.hashFloat(v)
+	case string:
+		return h.hashString(v)
+	case []byte:
+		return h.hashBytes(v)
+	case protoreflect.EnumNumber:
+		return h.hashEnum(v)
+	case protoreflect.Message:
+		return h.hashMessage(v)
+	case protoreflect.List:
+		var buf bytes.Buffer
+		for i := 0; i < v.Len(); i++ {
+			vhash, err := h.hashLeafValue(v.Get(i))
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(vhash)
+		}
+		return h.digest.hash(listIdentifier, buf.Bytes())
+	case protoreflect.Map:
+		var entries []hashMapEntry
+		var err error
+		v.Range(func(mk protoreflect.MapKey, mv protoreflect.Value) bool {
+			var e hashMapEntry
+			if e.khash, err = h.hashLeafValue(mk.Value()); err != nil {
+				return false
+			}
+			if e.vhash, err = h.hashLeafValue(mv); err != nil {
+				return false
+			}
+			entries = append(entries, e)
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+		sort.Sort(byKHash(entries))
+		var buf bytes.Buffer
+		for _, e := range entries {
+			buf.Write(e.khash)
+			buf.Write(e.vhash)
+		}
+		return h.digest.hash(mapIdentifier, buf.Bytes())
+	}
+	return nil, fmt.Errorf("unexpected value: %v (%T)", value, value.Interface())
+}
+
+// splitEntries splits the pre-image of a node into entries of the given size.
+func splitEntries(b []byte, size int) ([][]byte, error) {
+	if size == 0 || len(b)%size != 0 {
+		return nil, fmt.Errorf("node of %d bytes does not hold entries of %d bytes", len(b), size)
+	}
+	return chunks(b, size), nil
+}
+
+// joinEntries joins the siblings with the entry inserted at the given index,
+// checking that all entries are the same size.
+func joinEntries(siblings [][]byte, index int, entry []byte) ([]byte, error) {
+	if index < 0 || index > len(siblings) {
+		return nil, fmt.Errorf("index %d out of range", index)
+	}
+	var buf bytes.Buffer
+	for i, sibling := range siblings {
+		if len(sibling) != len(entry) {
+			return nil, fmt.Errorf("sibling %d has %d bytes, want %d", i, len(sibling), len(entry))
+		}
+		if i == index {
+			buf.Write(entry)
+		}
+		buf.Write(sibling)
+	}
+	if index == len(siblings) {
+		buf.Write(entry)
+	}
+	return buf.Bytes(), nil
+}
+
+// proofNode is the identifier and pre-image of a node hashed along the path of
+// a proof, with its hash.
+type proofNode struct {
+	t   string
+	b   []byte
+	sum []byte
+}
+
+// proofVisitor records the nodes along the target path, which are the values
+// at each of its prefixes, and the key and value hashes of each step.
+type proofVisitor struct {
+	pathTracker
+	target  Path
+	last    proofNode
+	nodes   []proofNode
+	khashes [][]byte
+	vhashes [][]byte
+}
+
+// observe records the last node hashed.  As the tree is hashed bottom-up, the
+// last node hashed before exiting a value is the node of the value itself.
+func (v *proofVisitor) observe(t string, b []byte, sum []byte) {
+	v.last = proofNode{t: t, b: append([]byte(nil), b...), sum: sum}
+}
+
+func (v *proofVisitor) onTarget() bool {
+	return v.target.hasPrefix(v.path)
+}
+
+func (v *proofVisitor) enter(step PathStep, khash []byte) []byte {
+	v.push(step)
+	if v.onTarget() {
+		v.khashes[len(v.path)-1] = khash
+	}
+	return nil
+}
+
+func (v *proofVisitor) exit(vhash []byte) {
+	if v.onTarget() {
+		depth := len(v.path)
+		v.vhashes[depth-1] = vhash
+		if depth < len(v.target) && bytes.Equal(v.last.sum, vhash) {
+			v.nodes[depth] = v.last
+		}
+	}
+	v.pop()
+}
+
+func (v *proofVisitor) absentFields(h *hasher, msg protoreflect.Message) ([]*fieldHashEntry, error) {
+	return nil, nil
+}
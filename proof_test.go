@@ -0,0 +1,586 @@
+package protoreflecthash
+
+import (
+	"encoding/json"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb2_latest "github.com/stackb/protoreflecthash/test_protos/generated/latest/proto2"
+	pb3_latest "github.com/stackb/protoreflecthash/test_protos/generated/latest/proto3"
+)
+
+func TestProve(t *testing.T) {
+	person := &pb3_latest.PersonV4{
+		Id:         1,
+		Age:        42,
+		Profession: "pilot",
+		StructuredName: &pb3_latest.PersonV4_NameV4{
+			First: "Amelia",
+			Last:  "Earhart",
+		},
+		Children: []*pb3_latest.PersonV3{
+			{Id: 2, Profession: "navigator"},
+			{Id: 3, Age: 7},
+			{Id: 2, Profession: "navigator"},
+		},
+	}
+	maps := &pb3_latest.StringMaps{
+		StringToString: map[string]string{"env": "prod", "region": "eu"},
+		StringToSimple: map[string]*pb3_latest.Simple{"a": {Int32Field: 5}},
+	}
+	ts := timestamppb.Now()
+	withExtensions := &pb2_latest.BadWithExtensions{Text: proto.String("text")}
+	proto.SetExtension(withExtensions, pb2_latest.E_StringExtension, "ext")
+
+	for name, tc := range map[string]struct {
+		options []Option
+		msg     proto.Message
+		path    string
+		leaf    protoreflect.Value
+	}{
+		"scalar field": {
+			msg:  person,
+			path: "profession",
+			leaf: protoreflect.ValueOfString("pilot"),
+		},
+		"nested field": {
+			msg:  person,
+			path: "structured_name.last",
+			leaf: protoreflect.ValueOfString("Earhart"),
+		},
+		"message field": {
+			msg:  person,
+			path: "structured_name",
+			leaf: protoreflect.ValueOfMessage(person.StructuredName.ProtoReflect()),
+		},
+		"list": {
+			msg:  person,
+			path: "children",
+			leaf: person.ProtoReflect().Get(person.ProtoReflect().Descriptor().Fields().ByName("children")),
+		},
+		"list element": {
+			msg:  person,
+			path: "children[1]",
+			leaf: protoreflect.ValueOfMessage(person.Children[1].ProtoReflect()),
+		},
+		"duplicate list element": {
+			msg:  person,
+			path: "children[2].profession",
+			leaf: protoreflect.ValueOfString("navigator"),
+		},
+		"map value": {
+			msg:  maps,
+			path: `string_to_string["region"]`,
+			leaf: protoreflect.ValueOfString("eu"),
+		},
+		"map value field": {
+			msg:  maps,
+			path: `string_to_simple["a"].int32_field`,
+			leaf: protoreflect.ValueOfInt32(5),
+		},
+		"map": {
+			msg:  maps,
+			path: "string_to_string",
+			leaf: maps.ProtoReflect().Get(maps.ProtoReflect().Descriptor().Fields().ByName("string_to_string")),
+		},
+		"well-known type": {
+			msg:  &pb3_latest.KnownTypes{TimestampField: ts},
+			path: "timestamp_field",
+			leaf: protoreflect.ValueOfMessage(ts.ProtoReflect()),
+		},
+		"extension": {
+			msg:  withExtensions,
+			path: "(schema.proto2.string_extension)",
+			leaf: protoreflect.ValueOfString("ext"),
+		},
+		"extension with field names as keys": {
+			options: []Option{FieldNamesAsKeys()},
+			msg:     withExtensions,
+			path:    "(schema.proto2.string_extension)",
+			leaf:    protoreflect.ValueOfString("ext"),
+		},
+		"field names as keys": {
+			options: []Option{FieldNamesAsKeys()},
+			msg:     person,
+			path:    "children[0].profession",
+			leaf:    protoreflect.ValueOfString("navigator"),
+		},
+		"message fullname identifier": {
+			options: []Option{MessageFullnameIdentifier()},
+			msg:     person,
+			path:    "structured_name.first",
+			leaf:    protoreflect.ValueOfString("Amelia"),
+		},
+		"hmac root": {
+			options: []Option{HMAC("k", []byte("key"), KeyRoot)},
+			msg:     person,
+			path:    "children[1].age",
+			leaf:    protoreflect.ValueOfUint32(7),
+		},
+		"hmac all nodes": {
+			options: []Option{HMAC("k", []byte("key"), KeyAllNodes)},
+			msg:     person,
+			path:    "children[1].age",
+			leaf:    protoreflect.ValueOfUint32(7),
+		},
+		"sha384": {
+			options: []Option{SHA384()},
+			msg:     maps,
+			path:    `string_to_string["env"]`,
+			leaf:    protoreflect.ValueOfString("prod"),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			h := NewHasher(tc.options...).(ProvingProtoHasher)
+			root, err := h.HashProto(tc.msg.ProtoReflect())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			proof, err := h.Prove(tc.msg.ProtoReflect(), tc.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Proofs survive a JSON round trip.
+			data, err := json.Marshal(proof)
+			if err != nil {
+				t.Fatal(err)
+			}
+			proof = &Proof{}
+			if err := json.Unmarshal(data, proof); err != nil {
+				t.Fatal(err)
+			}
+
+			ok, err := VerifyProof(root, tc.path, tc.leaf, proof, tc.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ok {
+				t.Error("VerifyProof: want true, got false")
+			}
+
+			ok, err = VerifyProof(root, tc.path, protoreflect.ValueOfString("tampered"), proof, tc.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok {
+				t.Error("VerifyProof (tampered leaf): want false, got true")
+			}
+		})
+	}
+}
+
+func TestVerifyProofTampered(t *testing.T) {
+	person := &pb3_latest.PersonV4{
+		Id:         1,
+		Profession: "pilot",
+		Children: []*pb3_latest.PersonV3{
+			{Id: 2, Profession: "navigator"},
+			{Id: 3, Profession: "navigator"},
+		},
+	}
+	h := NewHasher().(ProvingProtoHasher)
+	root, err := h.HashProto(person.ProtoReflect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	const path = "children[1].profession"
+	leaf := protoreflect.ValueOfString("navigator")
+
+	for name, tc := range map[string]struct {
+		path   string
+		tamper func(p *Proof)
+		err    bool
+	}{
+		"root": {
+			tamper: func(p *Proof) {},
+		},
+		"other list index": {
+			path:   "children[0].profession",
+			tamper: func(p *Proof) {},
+			err:    true,
+		},
+		"moved list index": {
+			path: "children[0].profession",
+			tamper: func(p *Proof) {
+				p.Steps[1].Index = 0
+			},
+		},
+		"other field number": {
+			tamper: func(p *Proof) {
+				p.Steps[2].FieldNumber = 2
+			},
+		},
+		"scalar identifier": {
+			tamper: func(p *Proof) {
+				p.Steps[2].Identifier = unicodeIndentifier
+			},
+			err: true,
+		},
+		"truncated sibling": {
+			tamper: func(p *Proof) {
+				p.Steps[0].Siblings[0] = p.Steps[0].Siblings[0][1:]
+			},
+			err: true,
+		},
+		"dropped sibling": {
+			tamper: func(p *Proof) {
+				p.Steps[0].Siblings = p.Steps[0].Siblings[1:]
+				p.Steps[0].Index--
+			},
+		},
+		"missing step": {
+			tamper: func(p *Proof) {
+				p.Steps = p.Steps[1:]
+			},
+			err: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			proof, err := h.Prove(person.ProtoReflect(), path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tc.tamper(proof)
+
+			verifyPath := path
+			if tc.path != "" {
+				verifyPath = tc.path
+			}
+			wantRoot := root
+			if name == "root" {
+				wantRoot = append([]byte(nil), root...)
+				wantRoot[0] ^= 1
+			}
+
+			ok, err := VerifyProof(wantRoot, verifyPath, leaf, proof)
+			if tc.err {
+				if err == nil {
+					t.Error("VerifyProof: want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok {
+				t.Error("VerifyProof: want false, got true")
+			}
+		})
+	}
+}
+
+func TestProveMapKeys(t *testing.T) {
+	h := NewHasher().(ProvingProtoHasher)
+	msg := newMapKeysMessage(t)
+
+	root, err := h.HashProto(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range mapKeyPaths {
+		leaf, _, err := MustParsePath(path).Lookup(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		proof, err := h.Prove(msg, path)
+		if err != nil {
+			t.Fatalf("Prove(%q): %v", path, err)
+		}
+		ok, err := VerifyProof(root, path, leaf, proof)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			t.Errorf("VerifyProof(%q): want true, got false", path)
+		}
+	}
+}
+
+func TestProveErrors(t *testing.T) {
+	person := &pb3_latest.PersonV4{Id: 1}
+	h := NewHasher().(ProvingProtoHasher)
+	for name, path := range map[string]string{
+		"empty":       "",
+		"unparseable": "id[",
+		"unset field": "age",
+		"within leaf": "id.value",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := h.Prove(person.ProtoReflect(), path); err == nil {
+				t.Errorf("Prove(%q): want error", path)
+			}
+		})
+	}
+}
+
+func TestProveAbsence(t *testing.T) {
+	person := &pb3_latest.PersonV4{
+		Id:         1,
+		Profession: "pilot",
+		StructuredName: &pb3_latest.PersonV4_NameV4{
+			First: "Amelia",
+		},
+		Children: []*pb3_latest.PersonV3{
+			{Id: 2},
+		},
+	}
+	maps := &pb3_latest.StringMaps{
+		StringToString: map[string]string{"a": "1", "m": "2", "z": "3"},
+	}
+	withExtensions := &pb2_latest.BadWithExtensions{Text: proto.String("text")}
+	proto.SetExtension(withExtensions, pb2_latest.E_StringExtension, "ext")
+
+	for name, tc := range map[string]struct {
+		options []Option
+		msg     proto.Message
+		path    string
+	}{
+		"first field": {
+			msg:  &pb3_latest.PersonV4{Profession: "pilot"},
+			path: "id",
+		},
+		"middle field": {
+			msg:  person,
+			path: "age",
+		},
+		"last field": {
+			msg:  &pb3_latest.PersonV4{Id: 1},
+			path: "structured_name",
+		},
+		"empty message": {
+			msg:  &pb3_latest.PersonV4{},
+			path: "age",
+		},
+		"nested field": {
+			msg:  person,
+			path: "structured_name.last",
+		},
+		"field of list element": {
+			msg:  person,
+			path: "children[0].profession",
+		},
+		"map key": {
+			msg:  maps,
+			path: `string_to_string["q"]`,
+		},
+		"extension": {
+			msg:  &pb2_latest.BadWithExtensions{Text: proto.String("text")},
+			path: "(schema.proto2.string_extension)",
+		},
+		"field of message with extensions": {
+			msg:  withExtensions,
+			path: "(schema.proto2.int32_extension)",
+		},
+		"field names as keys": {
+			options: []Option{FieldNamesAsKeys()},
+			msg:     person,
+			path:    "age",
+		},
+		"message fullname identifier": {
+			options: []Option{MessageFullnameIdentifier()},
+			msg:     person,
+			path:    "structured_name.last",
+		},
+		"hmac root": {
+			options: []Option{HMAC("k", []byte("key"), KeyRoot)},
+			msg:     person,
+			path:    "age",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			h := NewHasher(tc.options...).(ProvingProtoHasher)
+			root, err := h.HashProto(tc.msg.ProtoReflect())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			proof, err := h.ProveAbsence(tc.msg.ProtoReflect(), tc.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ok, err := VerifyAbsenceProof(root, tc.path, proof, tc.options...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ok {
+				t.Error("VerifyAbsenceProof: want true, got false")
+			}
+		})
+	}
+}
+
+func TestVerifyAbsenceProofTampered(t *testing.T) {
+	person := &pb3_latest.PersonV4{Id: 1, Age: 42, Profession: "pilot"}
+	maps := &pb3_latest.StringMaps{
+		StringToString: map[string]string{"a": "1", "m": "2", "z": "3"},
+	}
+	h := NewHasher().(ProvingProtoHasher)
+
+	for name, tc := range map[string]struct {
+		msg    proto.Message
+		path   string
+		verify string
+		tamper func(p *Proof)
+		err    bool
+	}{
+		"present field": {
+			msg:    person,
+			path:   "children",
+			verify: "age",
+			tamper: func(p *Proof) {
+				p.Steps[0].FieldNumber = 3
+			},
+		},
+		"dropped entry": {
+			msg:  person,
+			path: "children",
+			tamper: func(p *Proof) {
+				p.Steps[0].Siblings = p.Steps[0].Siblings[1:]
+				p.Steps[0].Index--
+			},
+		},
+		"present map key": {
+			msg:    maps,
+			path:   `string_to_string["q"]`,
+			verify: `string_to_string["m"]`,
+			tamper: func(p *Proof) {},
+		},
+		"moved map key": {
+			msg:  maps,
+			path: `string_to_string["q"]`,
+			tamper: func(p *Proof) {
+				p.Steps[1].Index = (p.Steps[1].Index + 1) % 4
+			},
+		},
+		"unsorted map entries": {
+			msg:  maps,
+			path: `string_to_string["q"]`,
+			tamper: func(p *Proof) {
+				s := p.Steps[1].Siblings
+				s[0], s[2] = s[2], s[0]
+			},
+		},
+		"list identifier": {
+			msg:  maps,
+			path: `string_to_string["q"]`,
+			tamper: func(p *Proof) {
+				p.Steps[1].Identifier = listIdentifier
+			},
+			err: true,
+		},
+		"truncated entry": {
+			msg:  person,
+			path: "children",
+			tamper: func(p *Proof) {
+				p.Steps[0].Siblings[0] = p.Steps[0].Siblings[0][1:]
+			},
+			err: true,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			root, err := h.HashProto(tc.msg.ProtoReflect())
+			if err != nil {
+				t.Fatal(err)
+			}
+			proof, err := h.ProveAbsence(tc.msg.ProtoReflect(), tc.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			tc.tamper(proof)
+
+			verify := tc.path
+			if tc.verify != "" {
+				verify = tc.verify
+			}
+			ok, err := VerifyAbsenceProof(root, verify, proof)
+			if tc.err {
+				if err == nil {
+					t.Error("VerifyAbsenceProof: want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok {
+				t.Error("VerifyAbsenceProof: want false, got true")
+			}
+		})
+	}
+}
+
+func TestProveAbsenceMapKeys(t *testing.T) {
+	h := NewHasher().(ProvingProtoHasher)
+	msg := newMapKeysMessage(t)
+
+	root, err := h.HashProto(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{
+		"uint32_to_value[6]",
+		"uint64_to_value[4]",
+		"uint64_to_value[18446744073709551614]",
+		"int64_to_value[-8]",
+		"bool_to_value[false]",
+	} {
+		proof, err := h.ProveAbsence(msg, path)
+		if err != nil {
+			t.Fatalf("ProveAbsence(%q): %v", path, err)
+		}
+		ok, err := VerifyAbsenceProof(root, path, proof)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			t.Errorf("VerifyAbsenceProof(%q): want true, got false", path)
+		}
+	}
+
+	// Present keys are found as such.
+	for _, path := range mapKeyPaths {
+		path = path[:len(path)-len(".s")]
+		if _, err := h.ProveAbsence(msg, path); err == nil {
+			t.Errorf("ProveAbsence(%q): want error", path)
+		}
+	}
+}
+
+func TestProveAbsenceErrors(t *testing.T) {
+	person := &pb3_latest.PersonV4{
+		Id:       1,
+		Children: []*pb3_latest.PersonV3{{Id: 2}},
+	}
+	maps := &pb3_latest.StringMaps{StringToString: map[string]string{"a": "1"}}
+	h := NewHasher().(ProvingProtoHasher)
+
+	for name, tc := range map[string]struct {
+		msg  proto.Message
+		path string
+	}{
+		"present field":     {msg: person, path: "id"},
+		"no such field":     {msg: person, path: "nickname"},
+		"absent parent":     {msg: person, path: "structured_name.first"},
+		"list index":        {msg: person, path: "children[1]"},
+		"field of list":     {msg: person, path: "children.id"},
+		"present map key":   {msg: maps, path: `string_to_string["a"]`},
+		"absent map":        {msg: maps, path: `string_to_simple["a"]`},
+		"wrong map key":     {msg: maps, path: "string_to_string[1]"},
+		"unknown extension": {msg: &pb2_latest.BadWithExtensions{}, path: "(schema.proto2.nope)"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := h.ProveAbsence(tc.msg.ProtoReflect(), tc.path); err == nil {
+				t.Errorf("ProveAbsence(%q): want error", tc.path)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package protoreflecthash
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// hashUnknownFields hashes the unknown fields of the message according to the
+// unknown fields mode.
+func (h *hasher) hashUnknownFields(msg protoreflect.Message) ([]*fieldHashEntry, error) {
+	return h.hashUnknown(msg.Descriptor(), msg.GetUnknown())
+}
+
+// hashUnknown hashes the unknown fields of a message of the given type, encoded
+// in unknown, according to the unknown fields mode.
+func (h *hasher) hashUnknown(md protoreflect.MessageDescriptor, unknown []byte) ([]*fieldHashEntry, error) {
+	if len(unknown) == 0 {
+		return nil, nil
+	}
+
+	switch h.unknownFieldsMode {
+	case UnknownFieldsReject:
+		numbers, err := rawFieldNumbers(unknown)
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %s %v", ErrUnknownFields, md.FullName(), numbers)
+	case UnknownFieldsInclude:
+		return h.hashRawFields(unknown)
+	}
+	return nil, nil
+}
+
+// rawFieldNumbers returns the distinct field numbers present in the
+// wire-format data b, in the order they first appear.
+func rawFieldNumbers(b []byte) ([]protowire.Number, error) {
+	var numbers []protowire.Number
+	seen := make(map[protowire.Number]bool)
+
+	for len(b) > 0 {
+		num, _, n := protowire.ConsumeField(b)
+		if n < 0 {
+			return nil, fmt.Errorf("parsing field: %w", protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		if !seen[num] {
+			seen[num] = true
+			numbers = append(numbers, num)
+		}
+	}
+
+	return numbers, nil
+}
+
+// hashRawMessage computes the hash of a serialized message without the aid of
+// a descriptor.  Fields are keyed by field number and hashed as a list of the
+// values they were encoded with, in the order they appear on the wire.
+func (h *hasher) hashRawMessage(b []byte) ([]byte, error) {
+	hashes, err := h.hashRawFields(b)
+	if err != nil {
+		return nil, err
+	}
+	return h.hashFieldHashEntries(nil, hashes)
+}
+
+// hashRawFields parses the wire-format fields in b and returns a hash entry
+// for each distinct field number.  The value of each entry is the hash of the
+// list of all values encoded for that field number.
+func (h *hasher) hashRawFields(b []byte) ([]*fieldHashEntry, error) {
+	var numbers []protowire.Number
+	values := make(map[protowire.Number][][]byte)
+
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, fmt.Errorf("parsing field tag: %w", protowire.ParseError(n))
+		}
+		b = b[n:]
+
+		vhash, n, err := h.hashRawValue(num, typ, b)
+		if err != nil {
+			return nil, fmt.Errorf("hashing raw field %d: %w", num, err)
+		}
+		b = b[n:]
+
+		if _, ok := values[num]; !ok {
+			numbers = append(numbers, num)
+		}
+		values[num] = append(values[num], vhash)
+	}
+
+	hashes := make([]*fieldHashEntry, 0, len(numbers))
+	for _, num := range numbers {
+		khash, err := h.hashInt(int64(num))
+		if err != nil {
+			return nil, fmt.Errorf("hashing raw field key %d: %w", num, err)
+		}
+		vhash, err := h.hashRawList(values[num])
+		if err != nil {
+			return nil, fmt.Errorf("hashing raw field value %d: %w", num, err)
+		}
+		hashes = append(hashes, &fieldHashEntry{
+			number:  int32(num),
+			khash:   khash,
+			vhash:   vhash,
+			unknown: true,
+		})
+	}
+
+	return hashes, nil
+}
+
+// hashRawValue hashes a single wire-format value of the given type, returning
+// the hash and the number of bytes consumed.  Varint and fixed-width values are
+// hashed as unsigned integers, length-delimited values as bytes and groups as
+// nested raw messages.
+func (h *hasher) hashRawValue(num protowire.Number, typ protowire.Type, b []byte) ([]byte, int, error) {
+	switch typ {
+	case protowire.VarintType:
+		v, n := protowire.ConsumeVarint(b)
+		if n < 0 {
+			return nil, 0, protowire.ParseError(n)
+		}
+		hash, err := h.hashUint(v)
+		return hash, n, err
+	case protowire.Fixed32Type:
+		v, n := protowire.ConsumeFixed32(b)
+		if n < 0 {
+			return nil, 0, protowire.ParseError(n)
+		}
+		hash, err := h.hashUint(uint64(v))
+		return hash, n, err
+	case protowire.Fixed64Type:
+		v, n := protowire.ConsumeFixed64(b)
+		if n < 0 {
+			return nil, 0, protowire.ParseError(n)
+		}
+		hash, err := h.hashUint(v)
+		return hash, n, err
+	case protowire.BytesType:
+		v, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return nil, 0, protowire.ParseError(n)
+		}
+		hash, err := h.hashBytes(v)
+		return hash, n, err
+	case protowire.StartGroupType:
+		v, n := protowire.ConsumeGroup(num, b)
+		if n < 0 {
+			return nil, 0, protowire.ParseError(n)
+		}
+		hash, err := h.hashRawMessage(v)
+		return hash, n, err
+	}
+	return nil, 0, fmt.Errorf("unexpected wire type: %v", typ)
+}
+
+func (h *hasher) hashRawList(hashes [][]byte) ([]byte, error) {
+	w := h.digest.newNode(listIdentifier)
+	for _, vhash := range hashes {
+		w.write(vhash)
+	}
+	return w.appendSum(nil), nil
+}
@@ -0,0 +1,202 @@
+package protoreflecthash
+
+import (
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Redacted is a message having some of its values redacted: removed from the
+// message, with their hashes kept in their place so that the hash of the
+// redacted message is the same as that of the original one.
+type Redacted struct {
+	// Message is a copy of the original message without the redacted values.
+	// Redacted fields are cleared, whereas redacted list elements and map
+	// values are replaced by zero values so that the indices and keys of the
+	// others are unchanged.
+	Message protoreflect.Message
+	// Hashes maps the string form of the path of each redacted value to its
+	// hash.
+	Hashes map[string][]byte
+}
+
+// RedactingProtoHasher is implemented by the ProtoHasher returned by NewHasher.
+type RedactingProtoHasher interface {
+	ProtoHasher
+	// Redact returns a copy of msg having the values at the given paths
+	// redacted.  Each path must identify a field, list element or map entry
+	// that is present in msg; values within well-known types cannot be
+	// redacted individually.
+	Redact(msg protoreflect.Message, paths ...string) (*Redacted, error)
+	// HashRedacted returns the hash of the redacted message, which is the hash
+	// of the original message.  Unlike HashProto, it does not check that the
+	// message is valid, as redacting required fields leaves it invalid.
+	HashRedacted(r *Redacted) ([]byte, error)
+}
+
+// Redact implements RedactingProtoHasher.
+func (h *hasher) Redact(msg protoreflect.Message, paths ...string) (*Redacted, error) {
+	if msg == nil {
+		return nil, fmt.Errorf("cannot redact a nil message")
+	}
+
+	var parsed []Path
+	for _, s := range paths {
+		path, err := ParsePath(s)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := lookupPath(msg, path); err != nil {
+			return nil, fmt.Errorf("redacting %s: %w", path, err)
+		}
+		parsed = append(parsed, path)
+	}
+
+	// Values within redacted values are redacted along with them.
+	targets := make(map[string]Path)
+	for _, path := range parsed {
+		if !isWithinAny(path, parsed) {
+			targets[path.String()] = path
+		}
+	}
+
+	capture := &captureVisitor{targets: targets, hashes: make(map[string][]byte)}
+	ch := *h
+	ch.visitor = capture
+	if _, err := ch.hashProto(msg); err != nil {
+		return nil, err
+	}
+
+	redacted := proto.Clone(msg.Interface()).ProtoReflect()
+	for s, path := range targets {
+		if _, ok := capture.hashes[s]; !ok {
+			return nil, fmt.Errorf("redacting %s: value not hashed", s)
+		}
+		pv, err := lookupPath(redacted, path)
+		if err != nil {
+			return nil, fmt.Errorf("redacting %s: %w", s, err)
+		}
+		pv.remove()
+	}
+
+	return &Redacted{Message: redacted, Hashes: capture.hashes}, nil
+}
+
+// HashRedacted implements RedactingProtoHasher.
+func (h *hasher) HashRedacted(r *Redacted) ([]byte, error) {
+	v := &redactionVisitor{hashes: r.Hashes}
+	for s := range r.Hashes {
+		path, err := ParsePath(s)
+		if err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return nil, fmt.Errorf("invalid redacted path %q", s)
+		}
+		v.paths = append(v.paths, path)
+	}
+
+	rh := *h
+	rh.visitor = v
+	return rh.hashRoot(r.Message, (*hasher).hashMessage)
+}
+
+// isWithinAny reports whether the path lies strictly within any of the others.
+func isWithinAny(path Path, others []Path) bool {
+	for _, other := range others {
+		if len(other) < len(path) && path.hasPrefix(other) {
+			return true
+		}
+	}
+	return false
+}
+
+// remove clears the field, or replaces the list element or map value by a zero
+// value.
+func (pv *pathValue) remove() {
+	switch {
+	case pv.step.Key == nil:
+		pv.parent.Clear(pv.fd)
+	case pv.fd.IsList():
+		list := pv.parent.Mutable(pv.fd).List()
+		list.Set(pv.index, list.NewElement())
+	case pv.fd.IsMap():
+		m := pv.parent.Mutable(pv.fd).Map()
+		m.Set(pv.mapKey, m.NewValue())
+	}
+}
+
+// captureVisitor records the hashes of the values at the target paths.
+type captureVisitor struct {
+	pathTracker
+	targets map[string]Path
+	hashes  map[string][]byte
+}
+
+func (v *captureVisitor) enter(step PathStep, khash []byte) []byte {
+	v.push(step)
+	return nil
+}
+
+func (v *captureVisitor) exit(vhash []byte) {
+	if s := v.path.String(); v.targets[s] != nil {
+		v.hashes[s] = vhash
+	}
+	v.pop()
+}
+
+func (v *captureVisitor) absentFields(h *hasher, msg protoreflect.Message) ([]*fieldHashEntry, error) {
+	return nil, nil
+}
+
+// redactionVisitor substitutes the hashes of redacted values.
+type redactionVisitor struct {
+	pathTracker
+	hashes map[string][]byte
+	paths  []Path
+}
+
+func (v *redactionVisitor) enter(step PathStep, khash []byte) []byte {
+	v.push(step)
+	return v.hashes[v.path.String()]
+}
+
+func (v *redactionVisitor) exit(vhash []byte) {
+	v.pop()
+}
+
+// absentFields returns the hash entries of the redacted fields of the current
+// message.
+func (v *redactionVisitor) absentFields(h *hasher, msg protoreflect.Message) ([]*fieldHashEntry, error) {
+	var hashes []*fieldHashEntry
+	for _, path := range v.paths {
+		if len(path) != len(v.path)+1 || !path.hasPrefix(v.path) {
+			continue
+		}
+		step := path[len(path)-1]
+		if step.Key != nil {
+			continue
+		}
+
+		fd, err := h.resolveFieldStep(msg, step)
+		if err != nil {
+			return nil, fmt.Errorf("redacted field %s: %w", path, err)
+		}
+		if msg.Has(fd) {
+			// The field was put back; its hash is substituted by enter.
+			continue
+		}
+
+		khash, err := h.hashFieldKey(fd)
+		if err != nil {
+			return nil, fmt.Errorf("hashing field key %d (%s): %w", fd.Number(), fd.FullName(), err)
+		}
+		hashes = append(hashes, &fieldHashEntry{
+			number: int32(fd.Number()),
+			khash:  khash,
+			vhash:  v.hashes[path.String()],
+		})
+	}
+	return hashes, nil
+}
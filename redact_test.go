@@ -0,0 +1,300 @@
+package protoreflecthash
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb2_latest "github.com/stackb/protoreflecthash/test_protos/generated/latest/proto2"
+	pb3_latest "github.com/stackb/protoreflecthash/test_protos/generated/latest/proto3"
+)
+
+func TestRedact(t *testing.T) {
+	person := &pb3_latest.PersonV4{
+		Id:         1,
+		Age:        42,
+		Profession: "pilot",
+		StructuredName: &pb3_latest.PersonV4_NameV4{
+			First: "Amelia",
+			Last:  "Earhart",
+		},
+		Children: []*pb3_latest.PersonV3{
+			{Id: 2, Profession: "navigator"},
+			{Id: 3, Age: 7},
+		},
+	}
+
+	withExtensions := &pb2_latest.BadWithExtensions{Text: proto.String("text")}
+	proto.SetExtension(withExtensions, pb2_latest.E_StringExtension, "secret")
+	proto.SetExtension(withExtensions, pb2_latest.E_Extensions_SimpleExtension, &pb2_latest.Simple{
+		StringField: proto.String("nested secret"),
+		Int32Field:  proto.Int32(5),
+	})
+
+	for name, tc := range map[string]struct {
+		options []Option
+		msg     proto.Message
+		paths   []string
+		want    proto.Message
+	}{
+		"nothing": {
+			msg:  person,
+			want: person,
+		},
+		"scalar field": {
+			msg:   person,
+			paths: []string{"profession"},
+			want: &pb3_latest.PersonV4{
+				Id:             1,
+				Age:            42,
+				StructuredName: person.StructuredName,
+				Children:       person.Children,
+			},
+		},
+		"message field": {
+			msg:   person,
+			paths: []string{"structured_name"},
+			want: &pb3_latest.PersonV4{
+				Id:         1,
+				Age:        42,
+				Profession: "pilot",
+				Children:   person.Children,
+			},
+		},
+		"nested fields": {
+			msg:   person,
+			paths: []string{"structured_name.last", "children[0].profession", "children[1].age"},
+			want: &pb3_latest.PersonV4{
+				Id:             1,
+				Age:            42,
+				Profession:     "pilot",
+				StructuredName: &pb3_latest.PersonV4_NameV4{First: "Amelia"},
+				Children: []*pb3_latest.PersonV3{
+					{Id: 2},
+					{Id: 3},
+				},
+			},
+		},
+		"list element": {
+			msg:   person,
+			paths: []string{"children[0]"},
+			want: &pb3_latest.PersonV4{
+				Id:             1,
+				Age:            42,
+				Profession:     "pilot",
+				StructuredName: person.StructuredName,
+				Children: []*pb3_latest.PersonV3{
+					{},
+					person.Children[1],
+				},
+			},
+		},
+		"within redacted value": {
+			msg:   person,
+			paths: []string{"children[1].age", "children"},
+			want: &pb3_latest.PersonV4{
+				Id:             1,
+				Age:            42,
+				Profession:     "pilot",
+				StructuredName: person.StructuredName,
+			},
+		},
+		"scalar list element": {
+			msg:   &pb3_latest.Repetitive{StringField: []string{"a", "b", "c"}},
+			paths: []string{"string_field[1]"},
+			want:  &pb3_latest.Repetitive{StringField: []string{"a", "", "c"}},
+		},
+		"map value": {
+			msg: &pb3_latest.StringMaps{
+				StringToString: map[string]string{"env": "prod", "token": "hunter2"},
+			},
+			paths: []string{`string_to_string["token"]`},
+			want: &pb3_latest.StringMaps{
+				StringToString: map[string]string{"env": "prod", "token": ""},
+			},
+		},
+		"message map value field": {
+			msg: &pb3_latest.IntMaps{
+				IntToSimple: map[int64]*pb3_latest.Simple{-1: {StringField: "secret", Int32Field: 5}},
+			},
+			paths: []string{"int_to_simple[-1].string_field"},
+			want: &pb3_latest.IntMaps{
+				IntToSimple: map[int64]*pb3_latest.Simple{-1: {Int32Field: 5}},
+			},
+		},
+		"well-known type": {
+			msg:   &pb3_latest.KnownTypes{TimestampField: timestamppb.Now()},
+			paths: []string{"timestamp_field"},
+			want:  &pb3_latest.KnownTypes{},
+		},
+		"extensions": {
+			msg: withExtensions,
+			paths: []string{
+				"(schema.proto2.string_extension)",
+				"(schema.proto2.Extensions.simple_extension).string_field",
+			},
+			want: func() proto.Message {
+				want := &pb2_latest.BadWithExtensions{Text: proto.String("text")}
+				proto.SetExtension(want, pb2_latest.E_Extensions_SimpleExtension, &pb2_latest.Simple{
+					Int32Field: proto.Int32(5),
+				})
+				return want
+			}(),
+		},
+		"required field": {
+			msg:   &pb2_latest.BadWithRequirements{Text: proto.String("secret")},
+			paths: []string{"text"},
+			want:  &pb2_latest.BadWithRequirements{},
+		},
+		"field names as keys": {
+			options: []Option{FieldNamesAsKeys()},
+			msg:     person,
+			paths:   []string{"profession", "children[1]"},
+			want: &pb3_latest.PersonV4{
+				Id:             1,
+				Age:            42,
+				StructuredName: person.StructuredName,
+				Children: []*pb3_latest.PersonV3{
+					person.Children[0],
+					{},
+				},
+			},
+		},
+		"hmac root": {
+			options: []Option{HMAC("k", []byte("key"), KeyRoot)},
+			msg:     person,
+			paths:   []string{"structured_name.first"},
+			want: &pb3_latest.PersonV4{
+				Id:             1,
+				Age:            42,
+				Profession:     "pilot",
+				StructuredName: &pb3_latest.PersonV4_NameV4{Last: "Earhart"},
+				Children:       person.Children,
+			},
+		},
+		"hmac all nodes": {
+			options: []Option{HMAC("k", []byte("key"), KeyAllNodes)},
+			msg:     person,
+			paths:   []string{"structured_name.first"},
+			want: &pb3_latest.PersonV4{
+				Id:             1,
+				Age:            42,
+				Profession:     "pilot",
+				StructuredName: &pb3_latest.PersonV4_NameV4{Last: "Earhart"},
+				Children:       person.Children,
+			},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			h := NewHasher(tc.options...).(RedactingProtoHasher)
+
+			want, err := h.HashProto(tc.msg.ProtoReflect())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			redacted, err := h.Redact(tc.msg.ProtoReflect(), tc.paths...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, redacted.Message.Interface(), protocmp.Transform()); diff != "" {
+				t.Errorf("redacted message (-want +got):\n%s", diff)
+			}
+
+			got, err := h.HashRedacted(redacted)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Errorf("hash (-want +got):\n%s", diff)
+			}
+
+			if len(tc.paths) > 0 {
+				plain, err := h.HashRedacted(&Redacted{Message: redacted.Message})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if cmp.Equal(want, plain) {
+					t.Error("redacted message without hashes: want different hash")
+				}
+			}
+		})
+	}
+}
+
+func TestRedactMapKeys(t *testing.T) {
+	h := NewHasher().(RedactingProtoHasher)
+	msg := newMapKeysMessage(t)
+
+	want, err := h.HashProto(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, paths := range append([][]string{mapKeyPaths}, splitPaths(mapKeyPaths)...) {
+		redacted, err := h.Redact(msg, paths...)
+		if err != nil {
+			t.Fatalf("Redact(%q): %v", paths, err)
+		}
+		for _, path := range paths {
+			if _, _, err := MustParsePath(path).Lookup(redacted.Message); err == nil {
+				t.Errorf("Redact(%q): %s not redacted", paths, path)
+			}
+		}
+
+		got, err := h.HashRedacted(redacted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("Redact(%q): hash (-want +got):\n%s", paths, diff)
+		}
+	}
+}
+
+// splitPaths returns each of the paths on its own.
+func splitPaths(paths []string) [][]string {
+	split := make([][]string, len(paths))
+	for i, path := range paths {
+		split[i] = []string{path}
+	}
+	return split
+}
+
+func TestRedactErrors(t *testing.T) {
+	person := &pb3_latest.PersonV4{
+		Id:       1,
+		Children: []*pb3_latest.PersonV3{{Id: 2}},
+	}
+	known := &pb3_latest.KnownTypes{TimestampField: timestamppb.Now()}
+
+	for name, tc := range map[string]struct {
+		msg  proto.Message
+		path string
+	}{
+		"unparseable path":       {msg: person, path: "children["},
+		"no such field":          {msg: person, path: "nickname"},
+		"unset field":            {msg: person, path: "profession"},
+		"index out of range":     {msg: person, path: "children[1]"},
+		"key on list":            {msg: person, path: `children["a"]`},
+		"field of scalar":        {msg: person, path: "id.value"},
+		"field of list":          {msg: person, path: "children.id"},
+		"within well-known type": {msg: known, path: "timestamp_field.seconds"},
+		"missing map key":        {msg: &pb3_latest.StringMaps{StringToString: map[string]string{"a": "b"}}, path: `string_to_string["b"]`},
+		"map key of wrong type":  {msg: &pb3_latest.StringMaps{StringToString: map[string]string{"a": "b"}}, path: "string_to_string[1]"},
+		"map key out of range":   {msg: &pb3_latest.IntMaps{IntToString: map[int64]string{1: "b"}}, path: "int_to_string[18446744073709551615]"},
+		"unset extension":        {msg: &pb2_latest.BadWithExtensions{}, path: "(schema.proto2.string_extension)"},
+		"index into unset list":  {msg: &pb3_latest.Repetitive{}, path: "string_field[0]"},
+		"empty extension name":   {msg: person, path: "()"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			h := NewHasher().(RedactingProtoHasher)
+			if _, err := h.Redact(tc.msg.ProtoReflect(), tc.path); err == nil {
+				t.Errorf("Redact(%q): want error", tc.path)
+			}
+		})
+	}
+}
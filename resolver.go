@@ -0,0 +1,123 @@
+package protoreflecthash
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// PlaceholderError is returned when hashing a message whose descriptor is a
+// placeholder (for example, the message type of a field in a partially-linked
+// file, or of a weak field whose message type is not linked) that cannot be
+// resolved by the type resolver.
+type PlaceholderError struct {
+	// FullName is the name of the unresolved message type.
+	FullName protoreflect.FullName
+}
+
+// Error implements error.
+func (e *PlaceholderError) Error() string {
+	return fmt.Sprintf("unresolved placeholder message type: %s", e.FullName)
+}
+
+// resolvePlaceholder returns an equivalent of msg, whose descriptor must be a
+// placeholder, having the message type resolved by the type resolver.  As a
+// placeholder has no fields, the content of msg is entirely in its unknown
+// fields.
+func (h *hasher) resolvePlaceholder(msg protoreflect.Message) (protoreflect.Message, error) {
+	name := msg.Descriptor().FullName()
+
+	mt, err := h.getTypeResolver().FindMessageByName(name)
+	if errors.Is(err, protoregistry.NotFound) {
+		return nil, &PlaceholderError{FullName: name}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("resolving placeholder message type %s: %w", name, err)
+	}
+
+	resolved := mt.New()
+	if err := h.unmarshalOptions().Unmarshal(msg.GetUnknown(), resolved.Interface()); err != nil {
+		return nil, fmt.Errorf("unmarshaling placeholder message type %s: %w", name, err)
+	}
+
+	return resolved, nil
+}
+
+// FilesTypeResolver returns a message type resolver that looks up message
+// descriptors in the given files and creates dynamic messages for them.  This
+// is useful for hashing messages whose types are not linked into the binary,
+// such as those loaded from a FileDescriptorSet.  The returned resolver also
+// implements protoregistry.ExtensionTypeResolver.
+func FilesTypeResolver(files *protoregistry.Files) protoregistry.MessageTypeResolver {
+	return &filesTypeResolver{files: files}
+}
+
+type filesTypeResolver struct {
+	files *protoregistry.Files
+}
+
+// FindMessageByName implements protoregistry.MessageTypeResolver.
+func (r *filesTypeResolver) FindMessageByName(name protoreflect.FullName) (protoreflect.MessageType, error) {
+	d, err := r.files.FindDescriptorByName(name)
+	if err != nil {
+		return nil, err
+	}
+	md, ok := d.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, protoregistry.NotFound
+	}
+	return dynamicpb.NewMessageType(md), nil
+}
+
+// FindMessageByURL implements protoregistry.MessageTypeResolver.
+func (r *filesTypeResolver) FindMessageByURL(url string) (protoreflect.MessageType, error) {
+	return r.FindMessageByName(protoreflect.FullName(typeUrlName(url)))
+}
+
+// FindExtensionByName implements protoregistry.ExtensionTypeResolver.
+func (r *filesTypeResolver) FindExtensionByName(field protoreflect.FullName) (protoreflect.ExtensionType, error) {
+	d, err := r.files.FindDescriptorByName(field)
+	if err != nil {
+		return nil, err
+	}
+	xd, ok := d.(protoreflect.ExtensionDescriptor)
+	if !ok || !xd.IsExtension() {
+		return nil, protoregistry.NotFound
+	}
+	return dynamicpb.NewExtensionType(xd), nil
+}
+
+// FindExtensionByNumber implements protoregistry.ExtensionTypeResolver.
+func (r *filesTypeResolver) FindExtensionByNumber(message protoreflect.FullName, field protoreflect.FieldNumber) (protoreflect.ExtensionType, error) {
+	var found protoreflect.ExtensionDescriptor
+	r.files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		found = findExtension(fd.Extensions(), fd.Messages(), message, field)
+		return found == nil
+	})
+	if found == nil {
+		return nil, protoregistry.NotFound
+	}
+	return dynamicpb.NewExtensionType(found), nil
+}
+
+// findExtension searches the given extensions and those declared within the
+// given messages (recursively) for an extension of the named message having
+// the given field number.
+func findExtension(xds protoreflect.ExtensionDescriptors, mds protoreflect.MessageDescriptors, message protoreflect.FullName, field protoreflect.FieldNumber) protoreflect.ExtensionDescriptor {
+	for i := 0; i < xds.Len(); i++ {
+		xd := xds.Get(i)
+		if xd.ContainingMessage().FullName() == message && xd.Number() == field {
+			return xd
+		}
+	}
+	for i := 0; i < mds.Len(); i++ {
+		md := mds.Get(i)
+		if xd := findExtension(md.Extensions(), md.Messages(), message, field); xd != nil {
+			return xd
+		}
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+// Package server serves the hashing of protobuf messages over HTTP, so that
+// programs not written in Go can compute the same hashes.
+//
+// Messages are posted to /v1/hash/<type>, where <type> is the fully-qualified
+// name of their message type, encoded in protojson (Content-Type
+// application/json) or in binary (application/x-protobuf, application/protobuf
+// or application/octet-stream).  The response is a JSON HashResponse, holding
+// the hex-encoded hash and, if the tree query parameter is true, the hash tree
+// of the message:
+//
+//	$ curl -d '{"name": "Ada"}' -H 'Content-Type: application/json' localhost:8080/v1/hash/example.Person?tree=true
+//	{"type":"example.Person","hash":"…","tree":{…}}
+//
+// Failures are reported with an ErrorResponse, with the status 422
+// (Unprocessable Entity) for messages that cannot be decoded or hashed.
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	"github.com/stackb/protoreflecthash"
+)
+
+// HashPath is the path under which messages are hashed: messages of type T
+// are posted to HashPath + T.
+const HashPath = "/v1/hash/"
+
+// MaxBodyBytes is the maximum size of the messages posted.
+const MaxBodyBytes = 32 << 20
+
+// HashResponse is the body of the response to the hashing of a message.
+type HashResponse struct {
+	// Type is the fully-qualified name of the message type.
+	Type string `json:"type"`
+	// Hash is the hash of the message.
+	Hash protoreflecthash.HexBytes `json:"hash"`
+	// Tree is the hash tree of the message, if requested.
+	Tree *protoreflecthash.HashNode `json:"tree,omitempty"`
+}
+
+// ErrorResponse is the body of the response to a request that failed.
+type ErrorResponse struct {
+	// Error describes the failure.
+	Error string `json:"error"`
+}
+
+// Server is an http.Handler hashing the messages posted to it.
+type Server struct {
+	files    *protoregistry.Files
+	resolver resolver
+	hasher   protoreflecthash.ProtoHasher
+}
+
+// resolver resolves the message types and extensions of messages in protojson
+// and binary form.
+type resolver interface {
+	protoregistry.MessageTypeResolver
+	protoregistry.ExtensionTypeResolver
+}
+
+// New returns a server hashing messages of the types described by files, with
+// a hasher created with the given options.  The types of the
+// google.protobuf.Any messages are resolved in files, unless the options
+// include another TypeResolver.
+func New(files *protoregistry.Files, options ...protoreflecthash.Option) *Server {
+	resolver := protoreflecthash.FilesTypeResolver(files).(resolver)
+	options = append([]protoreflecthash.Option{protoreflecthash.TypeResolver(resolver)}, options...)
+	return &Server{
+		files:    files,
+		resolver: resolver,
+		hasher:   protoreflecthash.NewHasher(options...),
+	}
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, HashPath) {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no such endpoint: %s", r.URL.Path))
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", r.Method))
+		return
+	}
+
+	md, err := s.messageType(strings.TrimPrefix(r.URL.Path, HashPath))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	binary, err := isBinary(r.Header.Get("Content-Type"))
+	if err != nil {
+		writeError(w, http.StatusUnsupportedMediaType, err)
+		return
+	}
+	tree := false
+	if q := r.URL.Query().Get("tree"); q != "" {
+		if tree, err = strconv.ParseBool(q); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid tree parameter %q", q))
+			return
+		}
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, MaxBodyBytes))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("reading body: %w", err))
+		return
+	}
+
+	resp := &HashResponse{Type: string(md.FullName())}
+	if binary && !tree {
+		// Binary messages are hashed without being decoded.
+		resp.Hash, err = s.hasher.(protoreflecthash.WireProtoHasher).HashWire(md, body)
+		if err != nil {
+			writeError(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	msg, err := s.decode(md, body, binary)
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	if tree {
+		resp.Tree, err = s.hasher.(protoreflecthash.TreeProtoHasher).HashTree(msg)
+		if err == nil {
+			resp.Hash = resp.Tree.Hash
+		}
+	} else {
+		resp.Hash, err = s.hasher.HashProto(msg)
+	}
+	if err != nil {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// messageType returns the descriptor of the named message type.
+func (s *Server) messageType(name string) (protoreflect.MessageDescriptor, error) {
+	d, err := s.files.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil, fmt.Errorf("unknown message type %q", name)
+	}
+	md, ok := d.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a message type", name)
+	}
+	return md, nil
+}
+
+// decode decodes a message of the given type from its protojson or binary
+// form.  Required fields are left to the validation of the hasher, so that
+// both forms are validated alike.
+func (s *Server) decode(md protoreflect.MessageDescriptor, body []byte, binary bool) (protoreflect.Message, error) {
+	msg := dynamicpb.NewMessage(md)
+	var err error
+	if binary {
+		err = proto.UnmarshalOptions{Resolver: s.resolver, AllowPartial: true}.Unmarshal(body, msg)
+	} else {
+		err = protojson.UnmarshalOptions{Resolver: s.resolver, AllowPartial: true}.Unmarshal(body, msg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing message: %w", err)
+	}
+	return msg, nil
+}
+
+// isBinary reports whether the content type is the one of binary messages,
+// rather than protojson.
+func isBinary(contentType string) (bool, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false, fmt.Errorf("invalid content type %q", contentType)
+	}
+	switch mediaType {
+	case "application/json":
+		return false, nil
+	case "application/x-protobuf", "application/protobuf", "application/octet-stream":
+		return true, nil
+	}
+	return false, fmt.Errorf("unsupported content type %q", mediaType)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, &ErrorResponse{Error: err.Error()})
+}
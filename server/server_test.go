@@ -0,0 +1,267 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/stackb/protoreflecthash"
+	pb2_latest "github.com/stackb/protoreflecthash/test_protos/generated/latest/proto2"
+	pb3_latest "github.com/stackb/protoreflecthash/test_protos/generated/latest/proto3"
+)
+
+// loadFiles loads the descriptors of the test protoset.
+func loadFiles(t *testing.T) *protoregistry.Files {
+	t.Helper()
+	data, err := ioutil.ReadFile("../testdata/protoset.pb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		t.Fatal(err)
+	}
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func TestServer(t *testing.T) {
+	packed, err := anypb.New(&pb3_latest.Simple{StringField: "packed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := &pb3_latest.KnownTypes{AnyField: packed}
+	binary, err := proto.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	json, err := protojson.Marshal(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, options := range map[string][]protoreflecthash.Option{
+		"default":          nil,
+		"field names":      {protoreflecthash.FieldNamesAsKeys()},
+		"fullname and sha": {protoreflecthash.MessageFullnameIdentifier(), protoreflecthash.SHA512_256()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			h := protoreflecthash.NewHasher(options...)
+			hash, err := h.HashProto(msg.ProtoReflect())
+			if err != nil {
+				t.Fatal(err)
+			}
+			tree, err := h.(protoreflecthash.TreeProtoHasher).HashTree(msg.ProtoReflect())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ts := httptest.NewServer(New(loadFiles(t), options...))
+			defer ts.Close()
+
+			for _, tc := range []struct {
+				contentType string
+				body        []byte
+				query       string
+				want        *HashResponse
+			}{
+				{"application/json", json, "", &HashResponse{Type: "schema.proto3.KnownTypes", Hash: hash}},
+				{"application/json; charset=utf-8", json, "?tree=true", &HashResponse{Type: "schema.proto3.KnownTypes", Hash: hash, Tree: tree}},
+				{"application/x-protobuf", binary, "", &HashResponse{Type: "schema.proto3.KnownTypes", Hash: hash}},
+				{"application/octet-stream", binary, "?tree=1", &HashResponse{Type: "schema.proto3.KnownTypes", Hash: hash, Tree: tree}},
+			} {
+				var got HashResponse
+				status := post(t, ts.URL+HashPath+"schema.proto3.KnownTypes"+tc.query, tc.contentType, tc.body, &got)
+				if status != http.StatusOK {
+					t.Errorf("%s%s: want status 200, got %d", tc.contentType, tc.query, status)
+				}
+				if diff := cmp.Diff(tc.want, &got); diff != "" {
+					t.Errorf("%s%s: response mismatch (-want +got):\n%s", tc.contentType, tc.query, diff)
+				}
+			}
+		})
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	ts := httptest.NewServer(New(loadFiles(t)))
+	defer ts.Close()
+
+	for name, tc := range map[string]struct {
+		method      string
+		path        string
+		contentType string
+		body        string
+		status      int
+		error       string
+	}{
+		"no such endpoint": {
+			path:   "/v2/hash/schema.proto3.Simple",
+			status: http.StatusNotFound,
+			error:  "no such endpoint: /v2/hash/schema.proto3.Simple",
+		},
+		"get": {
+			method: http.MethodGet,
+			path:   HashPath + "schema.proto3.Simple",
+			status: http.StatusMethodNotAllowed,
+			error:  "method GET not allowed",
+		},
+		"unknown type": {
+			path:        HashPath + "schema.proto3.Nope",
+			contentType: "application/json",
+			status:      http.StatusNotFound,
+			error:       `unknown message type "schema.proto3.Nope"`,
+		},
+		"unsupported content type": {
+			path:        HashPath + "schema.proto3.Simple",
+			contentType: "text/plain",
+			status:      http.StatusUnsupportedMediaType,
+			error:       `unsupported content type "text/plain"`,
+		},
+		"invalid tree parameter": {
+			path:        HashPath + "schema.proto3.Simple?tree=maybe",
+			contentType: "application/json",
+			status:      http.StatusBadRequest,
+			error:       `invalid tree parameter "maybe"`,
+		},
+		"invalid message": {
+			path:        HashPath + "schema.proto2.BadWithRequirements",
+			contentType: "application/x-protobuf",
+			status:      http.StatusUnprocessableEntity,
+			error:       "invalid message: required field schema.proto2.BadWithRequirements.text not set",
+		},
+	} {
+		method := tc.method
+		if method == "" {
+			method = http.MethodPost
+		}
+		req, err := http.NewRequest(method, ts.URL+tc.path, bytes.NewReader([]byte(tc.body)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", tc.contentType)
+		var got ErrorResponse
+		if status := do(t, req, &got); status != tc.status {
+			t.Errorf("%s: want status %d, got %d", name, tc.status, status)
+		}
+		if got.Error != tc.error {
+			t.Errorf("%s: want error %q, got %q", name, tc.error, got.Error)
+		}
+	}
+}
+
+func TestServerValidation(t *testing.T) {
+	// An empty message lacks the required text field.
+	path := HashPath + "schema.proto2.BadWithRequirements"
+	bodies := []struct {
+		contentType string
+		body        []byte
+		query       string
+	}{
+		{"application/json", []byte("{}"), ""},
+		{"application/json", []byte("{}"), "?tree=true"},
+		{"application/x-protobuf", nil, ""},
+		{"application/x-protobuf", nil, "?tree=true"},
+	}
+
+	// Without validation, the partial message is hashed alike in both forms.
+	options := []protoreflecthash.Option{protoreflecthash.Validation(protoreflecthash.ValidateNone)}
+	want, err := protoreflecthash.NewHasher(options...).HashProto((&pb2_latest.BadWithRequirements{}).ProtoReflect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(New(loadFiles(t), options...))
+	defer ts.Close()
+	for _, tc := range bodies {
+		var got HashResponse
+		if status := post(t, ts.URL+path+tc.query, tc.contentType, tc.body, &got); status != http.StatusOK {
+			t.Errorf("%s%s: want status 200, got %d", tc.contentType, tc.query, status)
+		}
+		if !bytes.Equal(got.Hash, want) {
+			t.Errorf("%s%s: want hash %x, got %x", tc.contentType, tc.query, want, []byte(got.Hash))
+		}
+	}
+
+	// By default, it is rejected alike in both forms.
+	ts = httptest.NewServer(New(loadFiles(t)))
+	defer ts.Close()
+	for _, tc := range bodies {
+		var got ErrorResponse
+		if status := post(t, ts.URL+path+tc.query, tc.contentType, tc.body, &got); status != http.StatusUnprocessableEntity {
+			t.Errorf("%s%s: want status 422, got %d", tc.contentType, tc.query, status)
+		}
+		if !strings.Contains(got.Error, protoreflecthash.ErrInvalidMessage.Error()) {
+			t.Errorf("%s%s: want invalid message error, got %q", tc.contentType, tc.query, got.Error)
+		}
+	}
+}
+
+func TestServerHandler(t *testing.T) {
+	// The server can also be exercised without a listener.
+	msg := &pb2_latest.Simple{StringField: proto.String("handler")}
+	want, err := protoreflecthash.NewHasher().HashProto(msg.ProtoReflect())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, HashPath+"schema.proto2.Simple", bytes.NewReader([]byte(`{"stringField": "handler"}`)))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	New(loadFiles(t)).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("want status 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var got HashResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.Hash, want) {
+		t.Errorf("want hash %x, got %x", want, []byte(got.Hash))
+	}
+}
+
+// post posts the body to the URL and decodes the JSON response into v,
+// returning the status.
+func post(t *testing.T, url, contentType string, body []byte, v interface{}) int {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	return do(t, req, v)
+}
+
+// do sends the request and decodes the JSON response into v, returning the
+// status.
+func do(t *testing.T, req *http.Request, v interface{}) int {
+	t.Helper()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("want content type application/json, got %s", got)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode
+}
@@ -0,0 +1,224 @@
+package protoreflecthash
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// HashNode is a node of the hash tree of a message: the hash of a value
+// together with the nodes it was computed from.
+type HashNode struct {
+	// Path is the path of the value within the message.  It is empty for the
+	// root, and for nodes within well-known types, which have no path.
+	Path string `json:"path,omitempty"`
+	// Identifier is the type identifier of the node: "b", "f", "i", "n", "r"
+	// and "u" for leaves, "d" for messages (or their full name, with
+	// MessageFullnameIdentifier) and maps, "l" for lists and "s" for sets.
+	Identifier string `json:"identifier"`
+	// Key is the canonical form of the key of the node within its parent, for
+	// entries of messages and maps: the field number or name, or the map key.
+	Key string `json:"key,omitempty"`
+	// KeyHash is the hash of the key, for entries of messages and maps.
+	KeyHash HexBytes `json:"key_hash,omitempty"`
+	// Hash is the hash of the value.
+	Hash HexBytes `json:"hash"`
+	// Value is the canonical form of the value, for leaves.  Bytes are
+	// hex-encoded.
+	Value string `json:"value,omitempty"`
+	// Children are the nodes of the entries, elements or members of the
+	// value, in canonical order.
+	Children []*HashNode `json:"children,omitempty"`
+}
+
+// HexBytes is a byte slice encoded as a hex string in text formats.
+type HexBytes []byte
+
+// MarshalText implements encoding.TextMarshaler.
+func (b HexBytes) MarshalText() ([]byte, error) {
+	return []byte(hex.EncodeToString(b)), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (b *HexBytes) UnmarshalText(text []byte) error {
+	decoded, err := hex.DecodeString(string(text))
+	if err != nil {
+		return err
+	}
+	*b = decoded
+	return nil
+}
+
+// String returns the hex encoding of the bytes.
+func (b HexBytes) String() string {
+	return hex.EncodeToString(b)
+}
+
+// TreeProtoHasher is implemented by the ProtoHasher returned by NewHasher.
+type TreeProtoHasher interface {
+	ProtoHasher
+	// HashTree returns the hash tree of msg.  The hash of its root is the hash
+	// returned by HashProto.
+	HashTree(msg protoreflect.Message) (*HashNode, error)
+}
+
+// HashTree implements TreeProtoHasher.
+func (h *hasher) HashTree(msg protoreflect.Message) (*HashNode, error) {
+	b := &treeBuilder{scopes: []map[string][]*HashNode{{}}}
+
+	th := *h
+	th.visitor = b
+	observe := h.digest.observe
+	th.digest.observe = func(t string, pre []byte, sum []byte) {
+		b.observe(t, pre, sum)
+		if observe != nil {
+			observe(t, pre, sum)
+		}
+	}
+
+	hash, err := th.hashRoot(msg, (*hasher).hashProto)
+	if err != nil {
+		return nil, err
+	}
+	if b.last == nil {
+		return nil, fmt.Errorf("no node hashed")
+	}
+
+	// The root node is the last one hashed, although the hash observed for a
+	// well-known type differs from its hash if only the root is keyed.
+	root := b.last
+	root.Hash = hash
+	return root, nil
+}
+
+// treeBuilder builds the hash tree of a message from the nodes observed as it
+// is hashed.  As the tree is hashed bottom-up, the nodes of the entries,
+// elements or members of a value are hashed before the value itself, which
+// adopts them from the pending nodes by their hashes.  Pending nodes are
+// scoped to the value being hashed, so that a node is adopted by its own
+// parent rather than by another having a child with the same hash.
+type treeBuilder struct {
+	pathTracker
+	// khashes holds the key hash of each step of the current path.
+	khashes [][]byte
+	// scopes holds, for the root and each step of the current path, the nodes
+	// not yet adopted by a parent, by hash.
+	scopes []map[string][]*HashNode
+	// last is the last node hashed.
+	last *HashNode
+}
+
+func (b *treeBuilder) observe(t string, pre []byte, sum []byte) {
+	node := &HashNode{Identifier: t, Hash: sum}
+
+	switch {
+	case t == byteIdentifier:
+		node.Value = hex.EncodeToString(pre)
+	case isLeafIdentifier(t):
+		node.Value = string(pre)
+	case t == listIdentifier || t == setIdentifier:
+		for _, vhash := range chunks(pre, len(sum)) {
+			node.Children = append(node.Children, b.adopt(vhash, nil))
+		}
+	default:
+		entries := chunks(pre, 2*len(sum))
+		for _, entry := range entries {
+			khash, vhash := entry[:len(sum)], entry[len(sum):]
+			key := b.adopt(khash, func(n *HashNode) bool { return n.Path == "" })
+			child := b.adopt(vhash, func(n *HashNode) bool { return bytes.Equal(n.KeyHash, khash) })
+			child.Key = key.Value
+			child.KeyHash = khash
+			node.Children = append(node.Children, child)
+		}
+	}
+
+	b.add(node)
+	b.last = node
+}
+
+// add adds a pending node to the current scope.
+func (b *treeBuilder) add(node *HashNode) {
+	pending := b.scopes[len(b.scopes)-1]
+	pending[string(node.Hash)] = append(pending[string(node.Hash)], node)
+}
+
+// adopt removes the oldest pending node of the current scope having the given
+// hash, preferring one satisfying prefer, if not nil.  If there is no such
+// node (as when a hash is substituted rather than computed), a node having
+// only the hash is returned.
+func (b *treeBuilder) adopt(hash []byte, prefer func(*HashNode) bool) *HashNode {
+	pending := b.scopes[len(b.scopes)-1]
+	nodes := pending[string(hash)]
+	if len(nodes) == 0 {
+		return &HashNode{Hash: hash}
+	}
+
+	index := 0
+	if prefer != nil {
+		for i, node := range nodes {
+			if prefer(node) {
+				index = i
+				break
+			}
+		}
+	}
+	node := nodes[index]
+
+	if len(nodes) == 1 {
+		delete(pending, string(hash))
+	} else {
+		pending[string(hash)] = append(nodes[:index:index], nodes[index+1:]...)
+	}
+	return node
+}
+
+func (b *treeBuilder) enter(step PathStep, khash []byte) []byte {
+	b.push(step)
+	b.khashes = append(b.khashes, khash)
+	b.scopes = append(b.scopes, map[string][]*HashNode{})
+	return nil
+}
+
+// exit moves the node of the value, which is the last one hashed, to the scope
+// of its parent.
+func (b *treeBuilder) exit(vhash []byte) {
+	var node *HashNode
+	if b.last != nil && bytes.Equal(b.last.Hash, vhash) {
+		node = b.adopt(vhash, func(n *HashNode) bool { return n == b.last })
+		node.Path = b.path.String()
+		node.KeyHash = b.khashes[len(b.khashes)-1]
+	}
+
+	b.scopes = b.scopes[:len(b.scopes)-1]
+	b.khashes = b.khashes[:len(b.khashes)-1]
+	b.pop()
+
+	if node != nil {
+		b.add(node)
+	}
+}
+
+func (b *treeBuilder) absentFields(h *hasher, msg protoreflect.Message) ([]*fieldHashEntry, error) {
+	return nil, nil
+}
+
+// isLeafIdentifier reports whether t is the identifier of a leaf node.
+func isLeafIdentifier(t string) bool {
+	switch t {
+	case boolIdentifier, floatIdentifier, intIdentifier, nilIdentifier, byteIdentifier, unicodeIndentifier:
+		return true
+	}
+	return false
+}
+
+// chunks splits b into chunks of the given size, ignoring any remainder.
+func chunks(b []byte, size int) [][]byte {
+	var chunks [][]byte
+	for size > 0 && len(b) >= size {
+		chunks = append(chunks, b[:size])
+		b = b[size:]
+	}
+	return chunks
+}
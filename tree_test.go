@@ -0,0 +1,230 @@
+package protoreflecthash
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb3_latest "github.com/stackb/protoreflecthash/test_protos/generated/latest/proto3"
+)
+
+func TestHashTree(t *testing.T) {
+	var d digest
+	sum := func(t string, parts ...[]byte) []byte {
+		hash, err := d.hash(t, bytes.Join(parts, nil))
+		if err != nil {
+			panic(err)
+		}
+		return hash
+	}
+
+	k13 := sum("i", []byte("13"))
+	v13 := sum("i", []byte("5"))
+	k25 := sum("i", []byte("25"))
+	v25 := sum("u", []byte("foo"))
+	k31 := sum("i", []byte("31"))
+	nested := sum("d", k25, v25)
+
+	want := &HashNode{
+		Identifier: "d",
+		Hash:       sum("d", k13, v13, k25, v25, k31, nested),
+		Children: []*HashNode{
+			{Path: "int32_field", Identifier: "i", Key: "13", KeyHash: k13, Hash: v13, Value: "5"},
+			{Path: "string_field", Identifier: "u", Key: "25", KeyHash: k25, Hash: v25, Value: "foo"},
+			{
+				Path:       "simple_field",
+				Identifier: "d",
+				Key:        "31",
+				KeyHash:    k31,
+				Hash:       nested,
+				Children: []*HashNode{
+					{Path: "simple_field.string_field", Identifier: "u", Key: "25", KeyHash: k25, Hash: v25, Value: "foo"},
+				},
+			},
+		},
+	}
+
+	msg := &pb3_latest.Simple{
+		Int32Field:  5,
+		StringField: "foo",
+		SimpleField: &pb3_latest.Simple{StringField: "foo"},
+	}
+	h := NewHasher().(TreeProtoHasher)
+	got, err := h.HashTree(msg.ProtoReflect())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("(-want +got):\n%s", diff)
+	}
+
+	data, err := json.Marshal(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded HashNode
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, &decoded); diff != "" {
+		t.Errorf("JSON round trip (-want +got):\n%s", diff)
+	}
+}
+
+func TestHashTreeConsistency(t *testing.T) {
+	packed, err := anypb.New(&pb3_latest.Simple{StringField: "packed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	st, err := structpb.NewStruct(map[string]interface{}{
+		"a": 1.0,
+		"b": []interface{}{"x", "x", true, nil},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	person := &pb3_latest.PersonV4{
+		Id: 1,
+		Children: []*pb3_latest.PersonV3{
+			{Id: 2},
+			{Id: 3},
+			{Id: 2},
+		},
+	}
+	maps := &pb3_latest.StringMaps{
+		StringToString: map[string]string{"a": "same", "b": "same", "c": "other"},
+		StringToInt32:  map[string]int32{"a": 13},
+	}
+	known := &pb3_latest.KnownTypes{
+		AnyField:       packed,
+		StructField:    st,
+		TimestampField: timestamppb.Now(),
+	}
+	unknown := &pb3_latest.Simple{Int32Field: 1}
+	unknown.ProtoReflect().SetUnknown([]byte{0xf8, 0x01, 0x07}) // field 31, varint 7
+
+	for name, tc := range map[string]struct {
+		options []Option
+		msg     proto.Message
+		paths   []string
+	}{
+		"duplicate list elements": {
+			msg:   person,
+			paths: []string{"children", "children[0]", "children[0].id", "children[1]", "children[1].id", "children[2]", "children[2].id", "id"},
+		},
+		"duplicate map values": {
+			msg:   maps,
+			paths: []string{"string_to_int32", `string_to_int32["a"]`, "string_to_string", `string_to_string["a"]`, `string_to_string["b"]`, `string_to_string["c"]`},
+		},
+		"well-known types": {
+			msg:   known,
+			paths: []string{"any_field", "struct_field", "timestamp_field"},
+		},
+		"field mask": {
+			msg:   &pb3_latest.KnownTypes{AnyField: mustNewAny(t, &fieldmaskpb.FieldMask{Paths: []string{"a", "b.c"}})},
+			paths: []string{"any_field"},
+		},
+		"unknown fields": {
+			options: []Option{UnknownFields(UnknownFieldsInclude)},
+			msg:     unknown,
+			paths:   []string{"int32_field"},
+		},
+		"field names as keys": {
+			options: []Option{FieldNamesAsKeys()},
+			msg:     person,
+			paths:   []string{"children", "children[0]", "children[0].id", "children[1]", "children[1].id", "children[2]", "children[2].id", "id"},
+		},
+		"message fullname identifier": {
+			options: []Option{MessageFullnameIdentifier()},
+			msg:     known,
+			paths:   []string{"any_field", "struct_field", "timestamp_field"},
+		},
+		"hmac root": {
+			options: []Option{HMAC("k", []byte("key"), KeyRoot)},
+			msg:     person,
+			paths:   []string{"children", "children[0]", "children[0].id", "children[1]", "children[1].id", "children[2]", "children[2].id", "id"},
+		},
+		"hmac all nodes": {
+			options: []Option{HMAC("k", []byte("key"), KeyAllNodes)},
+			msg:     maps,
+			paths:   []string{"string_to_int32", `string_to_int32["a"]`, "string_to_string", `string_to_string["a"]`, `string_to_string["b"]`, `string_to_string["c"]`},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			hasher := NewHasher(tc.options...).(*hasher)
+			want, err := hasher.HashProto(tc.msg.ProtoReflect())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			root, err := hasher.HashTree(tc.msg.ProtoReflect())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(want, root.Hash) {
+				t.Errorf("root hash: want %x, got %x", want, root.Hash)
+			}
+
+			// Every node other than the root (which may be keyed differently)
+			// must hash to its hash from its value or children.
+			var paths []string
+			var check func(node *HashNode, isRoot bool)
+			check = func(node *HashNode, isRoot bool) {
+				if node.Path != "" {
+					paths = append(paths, node.Path)
+					path := MustParsePath(node.Path)
+					if key, ok := path[len(path)-1].Key.(string); ok && key != node.Key {
+						t.Errorf("node %q: want key %q, got %q", node.Path, key, node.Key)
+					}
+				}
+				var pre []byte
+				switch {
+				case node.Identifier == byteIdentifier:
+					var decoded HexBytes
+					if err := decoded.UnmarshalText([]byte(node.Value)); err != nil {
+						t.Fatal(err)
+					}
+					pre = decoded
+				case isLeafIdentifier(node.Identifier):
+					pre = []byte(node.Value)
+				case node.Identifier == listIdentifier || node.Identifier == setIdentifier:
+					for _, child := range node.Children {
+						pre = append(pre, child.Hash...)
+					}
+				default:
+					for _, child := range node.Children {
+						pre = append(pre, child.KeyHash...)
+						pre = append(pre, child.Hash...)
+					}
+				}
+				for _, child := range node.Children {
+					check(child, false)
+				}
+				if isRoot {
+					return
+				}
+				hash, err := hasher.digest.hash(node.Identifier, pre)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(hash, node.Hash) {
+					t.Errorf("node %q (%s): want hash %x, got %x", node.Path, node.Identifier, hash, node.Hash)
+				}
+			}
+			check(root, true)
+
+			sort.Strings(paths)
+			if diff := cmp.Diff(tc.paths, paths); diff != "" {
+				t.Errorf("paths (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package protoreflecthash
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// ErrInvalidMessage is returned, wrapped in a *ValidationError, when a message
+// fails the validation preceding hashing.
+var ErrInvalidMessage = errors.New("invalid message")
+
+// ValidationMode determines how messages are validated before being hashed.
+type ValidationMode int
+
+const (
+	// ValidateRequired checks that the required fields of the message and of
+	// the messages it contains are set, and that strings of proto3 fields are
+	// valid UTF-8, as marshaling the message would.  This is the default.
+	ValidateRequired ValidationMode = iota
+	// ValidateNone hashes messages without validating them.  Messages having
+	// unset required fields or invalid UTF-8 are hashed as they are.
+	ValidateNone
+	// ValidateStrict checks what ValidateRequired does, and that the values
+	// hashed are valid for hashing: google.protobuf.Timestamp and
+	// google.protobuf.Duration values are within their documented ranges and
+	// google.protobuf.Value messages have a kind.
+	ValidateStrict
+)
+
+// Validation is an option that sets the mode for validating messages before
+// hashing them.
+func Validation(mode ValidationMode) Option {
+	return func(h *hasher) {
+		h.validation = mode
+	}
+}
+
+// ValidationError is returned when a message fails validation.  It wraps
+// ErrInvalidMessage.
+type ValidationError struct {
+	// Path is the path of the invalid value, or of the required field not
+	// set, within the message.
+	Path Path
+	// Reason describes why the value is invalid.
+	Reason string
+}
+
+// Error implements error.
+func (e *ValidationError) Error() string {
+	if len(e.Path) == 0 {
+		return fmt.Sprintf("%v: %s", ErrInvalidMessage, e.Reason)
+	}
+	return fmt.Sprintf("%v: %s: %s", ErrInvalidMessage, e.Path, e.Reason)
+}
+
+// Unwrap returns ErrInvalidMessage.
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidMessage
+}
+
+// validate validates msg according to the validation mode.
+func (h *hasher) validate(msg protoreflect.Message) error {
+	if h.validation == ValidateNone {
+		return nil
+	}
+	if err := proto.CheckInitialized(msg.Interface()); err != nil {
+		// The message is walked only once known to be invalid, to find the
+		// path of the unset field.
+		if path := missingRequiredField(nil, msg); path != nil {
+			return &ValidationError{Path: path, Reason: "required field not set"}
+		}
+		return &ValidationError{Reason: err.Error()}
+	}
+	if h.validation == ValidateStrict {
+		return validateMessage(nil, msg, true)
+	}
+	if hasInvalidUTF8(msg) {
+		// As for required fields, the path is only tracked once the message
+		// is known to be invalid.
+		return validateMessage(nil, msg, false)
+	}
+	return nil
+}
+
+// hasInvalidUTF8 reports whether a string of a proto3 field of msg, or of the
+// messages it contains, is not valid UTF-8.  Fields that cannot hold such
+// strings are not visited.
+func hasInvalidUTF8(msg protoreflect.Message) bool {
+	invalid := false
+	msg.Range(func(fd protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		switch {
+		case fd.IsMap():
+			kd, vd := fd.MapKey(), fd.MapValue()
+			if !mayHaveInvalidUTF8(kd) && !mayHaveInvalidUTF8(vd) {
+				return true
+			}
+			value.Map().Range(func(mk protoreflect.MapKey, v protoreflect.Value) bool {
+				invalid = isInvalidUTF8(kd, mk.Value()) || isInvalidUTF8(vd, v)
+				return !invalid
+			})
+		case fd.IsList():
+			if !mayHaveInvalidUTF8(fd) {
+				return true
+			}
+			list := value.List()
+			for i := 0; i < list.Len() && !invalid; i++ {
+				invalid = isInvalidUTF8(fd, list.Get(i))
+			}
+		default:
+			invalid = isInvalidUTF8(fd, value)
+		}
+		return !invalid
+	})
+	return invalid
+}
+
+// mayHaveInvalidUTF8 reports whether values of the field are strings checked
+// for UTF-8, or messages that may hold such strings.
+func mayHaveInvalidUTF8(fd protoreflect.FieldDescriptor) bool {
+	return isMessageKind(fd.Kind()) || isUTF8Checked(fd)
+}
+
+// isInvalidUTF8 reports whether the value of the given field is a string that
+// is not valid UTF-8, or a message holding one.
+func isInvalidUTF8(fd protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+	switch {
+	case isMessageKind(fd.Kind()):
+		return hasInvalidUTF8(value.Message())
+	case isUTF8Checked(fd):
+		return !utf8.ValidString(value.String())
+	}
+	return false
+}
+
+// isUTF8Checked reports whether the values of the field are strings that must
+// be valid UTF-8, which is the case of proto3 string fields.
+func isUTF8Checked(fd protoreflect.FieldDescriptor) bool {
+	return fd.Kind() == protoreflect.StringKind && fd.Syntax() == protoreflect.Proto3
+}
+
+// missingRequiredField returns the path of the first required field not set in
+// msg, at the given path, or nil if there is none.
+func missingRequiredField(path Path, msg protoreflect.Message) Path {
+	fields := msg.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.Cardinality() == protoreflect.Required && !msg.Has(fd) {
+			return append(append(Path{}, path...), fieldStep(fd))
+		}
+	}
+
+	var missing Path
+	msg.Range(func(fd protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		if !isMessageKind(fd.Kind()) && !(fd.IsMap() && isMessageKind(fd.MapValue().Kind())) {
+			return true
+		}
+		fieldPath := append(path, fieldStep(fd))
+		switch {
+		case fd.IsList():
+			list := value.List()
+			for i := 0; i < list.Len() && missing == nil; i++ {
+				missing = missingRequiredField(append(fieldPath, indexStep(i)), list.Get(i).Message())
+			}
+		case fd.IsMap():
+			value.Map().Range(func(mk protoreflect.MapKey, v protoreflect.Value) bool {
+				missing = missingRequiredField(append(fieldPath, mapKeyStep(mk)), v.Message())
+				return missing == nil
+			})
+		default:
+			missing = missingRequiredField(fieldPath, value.Message())
+		}
+		return missing == nil
+	})
+	return missing
+}
+
+// validateMessage checks the values of msg, at the given path, for hashing:
+// strings of proto3 fields must be valid UTF-8 and, if strict, well-known types
+// must be valid too.
+func validateMessage(path Path, msg protoreflect.Message, strict bool) error {
+	md := msg.Descriptor()
+	if strict {
+		if reason := checkWellKnownType(md, msg); reason != "" {
+			return &ValidationError{Path: append(Path{}, path...), Reason: reason}
+		}
+	}
+
+	var err error
+	msg.Range(func(fd protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		fieldPath := append(path, fieldStep(fd))
+		switch {
+		case fd.IsList():
+			list := value.List()
+			for i := 0; i < list.Len() && err == nil; i++ {
+				err = validateValue(append(fieldPath, indexStep(i)), fd, list.Get(i), strict)
+			}
+		case fd.IsMap():
+			value.Map().Range(func(mk protoreflect.MapKey, v protoreflect.Value) bool {
+				entryPath := append(fieldPath, mapKeyStep(mk))
+				err = validateValue(entryPath, fd.MapKey(), mk.Value(), strict)
+				if err == nil {
+					err = validateValue(entryPath, fd.MapValue(), v, strict)
+				}
+				return err == nil
+			})
+		default:
+			err = validateValue(fieldPath, fd, value, strict)
+		}
+		return err == nil
+	})
+	return err
+}
+
+// validateValue checks a value of the given field, at the given path, for
+// hashing.
+func validateValue(path Path, fd protoreflect.FieldDescriptor, value protoreflect.Value, strict bool) error {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		if isUTF8Checked(fd) && !utf8.ValidString(value.String()) {
+			return &ValidationError{Path: append(Path{}, path...), Reason: "invalid UTF-8"}
+		}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return validateMessage(path, value.Message(), strict)
+	}
+	return nil
+}
+
+const (
+	// The range of google.protobuf.Timestamp, from 0001-01-01T00:00:00Z to
+	// 9999-12-31T23:59:59Z.
+	minTimestampSeconds = -62135596800
+	maxTimestampSeconds = 253402300799
+	// The range of google.protobuf.Duration, about 10,000 years.
+	maxDurationSeconds = 315576000000
+	maxNanos           = 999999999
+)
+
+// checkWellKnownType returns why msg, if of a well-known type, is not valid
+// for hashing, or an empty string.
+func checkWellKnownType(md protoreflect.MessageDescriptor, msg protoreflect.Message) string {
+	switch md.FullName() {
+	case "google.protobuf.Timestamp":
+		seconds := msg.Get(md.Fields().ByName("seconds")).Int()
+		nanos := msg.Get(md.Fields().ByName("nanos")).Int()
+		if seconds < minTimestampSeconds || seconds > maxTimestampSeconds {
+			return fmt.Sprintf("timestamp seconds out of range: %d", seconds)
+		}
+		if nanos < 0 || nanos > maxNanos {
+			return fmt.Sprintf("timestamp nanos out of range: %d", nanos)
+		}
+	case "google.protobuf.Duration":
+		seconds := msg.Get(md.Fields().ByName("seconds")).Int()
+		nanos := msg.Get(md.Fields().ByName("nanos")).Int()
+		if seconds < -maxDurationSeconds || seconds > maxDurationSeconds {
+			return fmt.Sprintf("duration seconds out of range: %d", seconds)
+		}
+		if nanos < -maxNanos || nanos > maxNanos {
+			return fmt.Sprintf("duration nanos out of range: %d", nanos)
+		}
+		if (seconds < 0 && nanos > 0) || (seconds > 0 && nanos < 0) {
+			return "duration seconds and nanos have different signs"
+		}
+	case "google.protobuf.Value":
+		if msg.WhichOneof(md.Oneofs().ByName("kind")) == nil {
+			return "value kind not set"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,173 @@
+package protoreflecthash
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	pb2_latest "github.com/stackb/protoreflecthash/test_protos/generated/latest/proto2"
+	pb3_latest "github.com/stackb/protoreflecthash/test_protos/generated/latest/proto3"
+)
+
+func TestValidation(t *testing.T) {
+	emptyValue := &structpb.Struct{Fields: map[string]*structpb.Value{
+		"ok":    structpb.NewBoolValue(true),
+		"empty": {},
+	}}
+
+	for name, tc := range map[string]struct {
+		msg proto.Message
+		// want is the error for each mode, or "" if the message is hashed.
+		want map[ValidationMode]string
+	}{
+		"valid": {
+			msg: &pb3_latest.KnownTypes{
+				TimestampField: &timestamppb.Timestamp{Seconds: maxTimestampSeconds, Nanos: maxNanos},
+				DurationField:  &durationpb.Duration{Seconds: -1, Nanos: -1},
+				StructField:    &structpb.Struct{Fields: map[string]*structpb.Value{"a": structpb.NewNullValue()}},
+			},
+		},
+		"missing required field": {
+			msg: &pb2_latest.BadWithRequirements{},
+			want: map[ValidationMode]string{
+				ValidateRequired: "invalid message: text: required field not set",
+				ValidateStrict:   "invalid message: text: required field not set",
+			},
+		},
+		"invalid UTF-8": {
+			msg: &pb3_latest.Repetitive{StringField: []string{"ok", "\xff"}},
+			want: map[ValidationMode]string{
+				ValidateRequired: "invalid message: string_field[1]: invalid UTF-8",
+				ValidateStrict:   "invalid message: string_field[1]: invalid UTF-8",
+			},
+		},
+		"invalid UTF-8 map key": {
+			msg: &pb3_latest.StringMaps{StringToInt32: map[string]int32{"\xff": 1}},
+			want: map[ValidationMode]string{
+				ValidateRequired: `invalid message: string_to_int32["\xff"]: invalid UTF-8`,
+				ValidateStrict:   `invalid message: string_to_int32["\xff"]: invalid UTF-8`,
+			},
+		},
+		"invalid UTF-8 in proto2": {
+			msg: &pb2_latest.Simple{StringField: proto.String("\xff")},
+		},
+		"timestamp out of range": {
+			msg: &pb3_latest.KnownTypes{TimestampField: &timestamppb.Timestamp{Seconds: maxTimestampSeconds + 1}},
+			want: map[ValidationMode]string{
+				ValidateStrict: "invalid message: timestamp_field: timestamp seconds out of range: 253402300800",
+			},
+		},
+		"timestamp nanos out of range": {
+			msg: &pb3_latest.KnownTypes{TimestampField: &timestamppb.Timestamp{Nanos: -1}},
+			want: map[ValidationMode]string{
+				ValidateStrict: "invalid message: timestamp_field: timestamp nanos out of range: -1",
+			},
+		},
+		"duration signs": {
+			msg: &pb3_latest.KnownTypes{DurationField: &durationpb.Duration{Seconds: 1, Nanos: -1}},
+			want: map[ValidationMode]string{
+				ValidateStrict: "invalid message: duration_field: duration seconds and nanos have different signs",
+			},
+		},
+		"value kind not set": {
+			msg: &pb3_latest.KnownTypes{StructField: emptyValue},
+			want: map[ValidationMode]string{
+				ValidateRequired: `hashing fields: hashing field value 11 (schema.proto3.KnownTypes.struct_field): hashing map key empty: invalid struct value: one value must be populated`,
+				ValidateNone:     `hashing fields: hashing field value 11 (schema.proto3.KnownTypes.struct_field): hashing map key empty: invalid struct value: one value must be populated`,
+				ValidateStrict:   `invalid message: struct_field.fields["empty"]: value kind not set`,
+			},
+		},
+	} {
+		for _, mode := range []ValidationMode{ValidateRequired, ValidateNone, ValidateStrict} {
+			_, err := NewHasher(Validation(mode)).HashProto(tc.msg.ProtoReflect())
+			var got string
+			if err != nil {
+				got = err.Error()
+			}
+			if want := tc.want[mode]; got != want {
+				t.Errorf("%s (mode %d): want error %q, got %q", name, mode, want, got)
+			}
+		}
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	_, err := NewHasher(Validation(ValidateStrict)).HashProto((&pb3_latest.Simple{StringField: "\xff"}).ProtoReflect())
+	if !errors.Is(err, ErrInvalidMessage) {
+		t.Fatalf("want error %v, got %v", ErrInvalidMessage, err)
+	}
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("want *ValidationError, got %T", err)
+	}
+	if got := verr.Path.String(); got != "string_field" {
+		t.Errorf("want path string_field, got %s", got)
+	}
+}
+
+func TestValidationNestedRequiredField(t *testing.T) {
+	required := (&pb2_latest.BadWithRequirements{}).ProtoReflect().Descriptor()
+	file, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:       proto.String("validate_test.proto"),
+		Package:    proto.String("validate"),
+		Dependency: []string{required.ParentFile().Path()},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Outer"),
+			Field: []*descriptorpb.FieldDescriptorProto{{
+				Name:     proto.String("items"),
+				Number:   proto.Int32(1),
+				Label:    descriptorpb.FieldDescriptorProto_LABEL_REPEATED.Enum(),
+				Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+				TypeName: proto.String("." + string(required.FullName())),
+			}},
+		}},
+	}, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	outer := dynamicpb.NewMessage(file.Messages().ByName("Outer"))
+	items := outer.Mutable(outer.Descriptor().Fields().ByName("items")).List()
+	items.Append(protoreflect.ValueOfMessage((&pb2_latest.BadWithRequirements{Text: proto.String("ok")}).ProtoReflect()))
+	items.Append(protoreflect.ValueOfMessage((&pb2_latest.BadWithRequirements{}).ProtoReflect()))
+
+	_, err = NewHasher().HashProto(outer)
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("want *ValidationError, got %v", err)
+	}
+	if got := verr.Path.String(); got != "items[1].text" {
+		t.Errorf("want path items[1].text, got %s", got)
+	}
+}
+
+func TestValidationWire(t *testing.T) {
+	md := (&pb2_latest.BadWithRequirements{}).ProtoReflect().Descriptor()
+
+	_, err := NewHasher().(WireProtoHasher).HashWire(md, nil)
+	if !errors.Is(err, ErrInvalidMessage) {
+		t.Errorf("want error %v, got %v", ErrInvalidMessage, err)
+	}
+
+	// Without validation, the partial message is hashed.
+	h := NewHasher(Validation(ValidateNone))
+	got, err := h.(WireProtoHasher).HashWire(md, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := h.HashProto(dynamicpb.NewMessage(md))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("want hash %x, got %x", want, got)
+	}
+}
@@ -0,0 +1,57 @@
+package protoreflecthash
+
+import (
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// visitor observes the values hashed within a message, identified by the path
+// steps leading to them.  Well-known types are hashed as leaves, so no path
+// leads into them.
+type visitor interface {
+	// enter is called before hashing the value reached by the given step from
+	// the current value, with the hash of the field or map key (nil for list
+	// elements).  If it returns a hash, that hash is used instead of hashing
+	// the value.
+	enter(step PathStep, khash []byte) []byte
+	// exit is called after hashing the value last entered, with its hash.
+	exit(vhash []byte)
+	// absentFields returns the hash entries of fields absent from the current
+	// message that must be hashed nonetheless.
+	absentFields(h *hasher, msg protoreflect.Message) ([]*fieldHashEntry, error)
+}
+
+// appendStep appends to dst the hash of the value reached by the step returned
+// by step, computed with appendFunc, notifying the visitor, if any.  The step is
+// only built when there is a visitor.
+func (h *hasher) appendStep(dst []byte, step func() PathStep, khash []byte, appendFunc func([]byte) ([]byte, error)) ([]byte, error) {
+	if h.visitor == nil {
+		return appendFunc(dst)
+	}
+
+	vhash := h.visitor.enter(step(), khash)
+	if vhash == nil {
+		// The visitor may retain the hash, so it is not appended to dst
+		// directly.
+		var err error
+		vhash, err = appendFunc(nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	h.visitor.exit(vhash)
+
+	return append(dst, vhash...), nil
+}
+
+// pathTracker tracks the path of the current value for a visitor.
+type pathTracker struct {
+	path Path
+}
+
+func (t *pathTracker) push(step PathStep) {
+	t.path = append(t.path, step)
+}
+
+func (t *pathTracker) pop() {
+	t.path = t.path[:len(t.path)-1]
+}
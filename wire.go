@@ -0,0 +1,640 @@
+package protoreflecthash
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"unicode/utf8"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// WireProtoHasher is implemented by the ProtoHasher returned by NewHasher.
+type WireProtoHasher interface {
+	ProtoHasher
+	// HashWire returns the object hash of the message of the given type
+	// serialized in b.  The hash is the one HashProto returns for the message
+	// unmarshaled from b, but it is computed from the wire format without
+	// building the message.
+	HashWire(md protoreflect.MessageDescriptor, b []byte) ([]byte, error)
+}
+
+// HashWire implements WireProtoHasher.
+//
+// Fields are decoded as proto.Unmarshal decodes them: the last value of a
+// singular scalar field wins, the values of a singular message field are
+// merged, repeated scalar fields are accepted packed or not, setting a member
+// of a oneof clears the others and fields encoded with an unexpected wire type
+// are unknown fields.  Well-known types and messages having extension ranges
+// are unmarshaled before being hashed.
+func (h *hasher) HashWire(md protoreflect.MessageDescriptor, b []byte) ([]byte, error) {
+	return h.hashRoot(nil, func(h *hasher, _ protoreflect.Message) ([]byte, error) {
+		return h.appendWireMessage(nil, md, b)
+	})
+}
+
+// wireRecord is a field of a message, as encoded in wire format.
+type wireRecord struct {
+	// field is the index of the field among the planned fields of the message,
+	// or droppedWireField for members of a oneof cleared by a later member.
+	field int
+	typ   protowire.Type
+	// value is the encoded value: the payload of length-delimited values and
+	// the content of groups.
+	value []byte
+}
+
+const droppedWireField = -1
+
+// wireMessage holds the fields of a message parsed from its wire format.
+type wireMessage struct {
+	records []wireRecord
+	// sorted reports whether the records are ordered by field.
+	sorted bool
+	// oneofs holds the index of the field of each oneof last set, or -1.
+	oneofs []int
+	// unknown holds the unknown fields, in wire format.
+	unknown []byte
+}
+
+// wireMessages pools the messages parsed from their wire format.
+var wireMessages = sync.Pool{
+	New: func() interface{} {
+		return new(wireMessage)
+	},
+}
+
+func (m *wireMessage) Len() int {
+	return len(m.records)
+}
+
+func (m *wireMessage) Less(i, j int) bool {
+	return m.records[i].field < m.records[j].field
+}
+
+func (m *wireMessage) Swap(i, j int) {
+	m.records[i], m.records[j] = m.records[j], m.records[i]
+}
+
+// parse parses the fields of a message of the planned type from b.  Unknown
+// fields are kept only if keepUnknown is set.
+func (m *wireMessage) parse(plan *messagePlan, md protoreflect.MessageDescriptor, b []byte, keepUnknown bool) error {
+	m.records = m.records[:0]
+	m.sorted = true
+	m.unknown = m.unknown[:0]
+	m.oneofs = m.oneofs[:0]
+	for i := 0; i < md.Oneofs().Len(); i++ {
+		m.oneofs = append(m.oneofs, -1)
+	}
+
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return fmt.Errorf("parsing field tag: %w", protowire.ParseError(n))
+		}
+		value, vn, err := consumeWireValue(num, typ, b[n:])
+		if err != nil {
+			return fmt.Errorf("parsing field %d: %w", num, err)
+		}
+		raw := b[:n+vn]
+		b = b[n+vn:]
+
+		field := plan.fieldIndex(num)
+		if field < 0 || !wireTypeMatches(plan.fields[field].fd, typ) {
+			if keepUnknown {
+				m.unknown = append(m.unknown, raw...)
+			}
+			continue
+		}
+		if od := plan.fields[field].fd.ContainingOneof(); od != nil {
+			if err := m.setOneof(plan, od.Index(), field); err != nil {
+				return err
+			}
+		}
+
+		if k := len(m.records); k > 0 && m.records[k-1].field > field {
+			m.sorted = false
+		}
+		m.records = append(m.records, wireRecord{field: field, typ: typ, value: value})
+	}
+
+	return nil
+}
+
+// setOneof records that the given field of a oneof is set.  Setting a member
+// of a oneof clears the member previously set, whose values are checked first
+// as they would have been decoded by proto.Unmarshal.
+func (m *wireMessage) setOneof(plan *messagePlan, oneof, field int) error {
+	previous := m.oneofs[oneof]
+	if previous == field {
+		return nil
+	}
+	if previous >= 0 {
+		fd := plan.fields[previous].fd
+		for i := range m.records {
+			if m.records[i].field == previous {
+				if err := checkWireValue(fd, m.records[i].value); err != nil {
+					return fmt.Errorf("parsing field %d: %w", fd.Number(), err)
+				}
+				m.records[i].field = droppedWireField
+				m.sorted = false
+			}
+		}
+	}
+	m.oneofs[oneof] = field
+	return nil
+}
+
+// checkWireValue checks that a value of a field is one proto.Unmarshal decodes,
+// for values that are dropped rather than hashed.  Required fields of messages
+// are not checked, as they are not part of the unmarshaled message.
+func checkWireValue(fd protoreflect.FieldDescriptor, b []byte) error {
+	if md := fd.Message(); md != nil {
+		return proto.UnmarshalOptions{AllowPartial: true}.Unmarshal(b, dynamicpb.NewMessage(md))
+	}
+	_, _, err := decodeWireScalar(fd, b)
+	return err
+}
+
+// release returns the message to the pool, without retaining the buffer it
+// was parsed from.
+func (m *wireMessage) release() {
+	for i := range m.records {
+		m.records[i] = wireRecord{}
+	}
+	wireMessages.Put(m)
+}
+
+// fieldIndex returns the index of the planned field having the given number,
+// or -1 if there is none.
+func (p *messagePlan) fieldIndex(num protowire.Number) int {
+	lo, hi := 0, len(p.fields)
+	for lo < hi {
+		mid := int(uint(lo+hi) >> 1)
+		switch n := protowire.Number(p.fields[mid].fd.Number()); {
+		case n == num:
+			return mid
+		case n < num:
+			lo = mid + 1
+		default:
+			hi = mid
+		}
+	}
+	return -1
+}
+
+// appendWireMessage appends to dst the hash of the message of the given type
+// serialized in b.
+func (h *hasher) appendWireMessage(dst []byte, md protoreflect.MessageDescriptor, b []byte) ([]byte, error) {
+	if md.IsPlaceholder() {
+		return h.appendUnmarshaledMessage(dst, md, b)
+	}
+	plan := h.plan(md)
+	if plan.wellKnown != nil || plan.extendable {
+		return h.appendUnmarshaledMessage(dst, md, b)
+	}
+
+	node, h := h.splitRoot()
+	m := wireMessages.Get().(*wireMessage)
+	defer m.release()
+	if err := m.parse(plan, md, b, h.unknownFieldsMode != UnknownFieldsIgnore); err != nil {
+		return nil, err
+	}
+	if !m.sorted {
+		// The values of each field are kept in wire order.
+		sort.Stable(m)
+	}
+
+	unknownHashes, err := h.hashUnknown(md, m.unknown)
+	if err != nil {
+		return nil, fmt.Errorf("hashing unknown fields: %w", err)
+	}
+	if len(unknownHashes) > 1 {
+		sort.SliceStable(unknownHashes, func(i, j int) bool {
+			return fieldHashEntryLess(unknownHashes[i], unknownHashes[j])
+		})
+	}
+
+	w := node.digest.newNode(plan.identifier)
+	// writeUnknown writes the hashes of the unknown fields preceding the known
+	// field having the given number, as ordered by fieldHashEntryLess.
+	writeUnknown := func(num int32) {
+		known := fieldHashEntry{number: num}
+		for len(unknownHashes) > 0 && fieldHashEntryLess(unknownHashes[0], &known) {
+			w.write(unknownHashes[0].khash)
+			w.write(unknownHashes[0].vhash)
+			unknownHashes = unknownHashes[1:]
+		}
+	}
+
+	records := m.records
+	for len(records) > 0 && records[0].field == droppedWireField {
+		records = records[1:]
+	}
+	for i := range plan.fields {
+		fp := &plan.fields[i]
+		fd := fp.fd
+
+		n := 0
+		for n < len(records) && records[n].field == i {
+			n++
+		}
+		fieldRecords := records[:n]
+		records = records[n:]
+		if n == 0 {
+			if fd.Cardinality() == protoreflect.Required && h.validation != ValidateNone {
+				w.release()
+				return nil, &ValidationError{Reason: fmt.Sprintf("required field %s not set", fd.FullName())}
+			}
+			continue
+		}
+
+		vhash, ok, err := h.appendWireField(w.child(), fp, fieldRecords)
+		if err != nil {
+			w.release()
+			return nil, fmt.Errorf("hashing fields: hashing field value %d (%s): %w", fd.Number(), fd.FullName(), err)
+		}
+		if !ok {
+			continue
+		}
+
+		writeUnknown(int32(fd.Number()))
+		if h.digest.observe == nil {
+			w.write(fp.khash)
+		} else {
+			w.write(h.appendFieldKey(nil, fd))
+		}
+		w.writeChild(vhash)
+	}
+	writeUnknown(math.MaxInt32)
+
+	return w.appendSum(dst), nil
+}
+
+// appendUnmarshaledMessage appends to dst the hash of the message of the given
+// type serialized in b, unmarshaling it first.
+func (h *hasher) appendUnmarshaledMessage(dst []byte, md protoreflect.MessageDescriptor, b []byte) ([]byte, error) {
+	msg := dynamicpb.NewMessage(md)
+	opts := h.unmarshalOptions()
+	opts.AllowPartial = h.validation == ValidateNone
+	if err := opts.Unmarshal(b, msg); err != nil {
+		return nil, fmt.Errorf("unmarshaling %s: %w", md.FullName(), err)
+	}
+	if h.validation == ValidateStrict {
+		if err := validateMessage(nil, msg, true); err != nil {
+			return nil, err
+		}
+	}
+	return h.appendMessage(dst, msg)
+}
+
+// appendWireField appends to dst the hash of the value of a field encoded in
+// the given records, and reports whether the field is populated.
+func (h *hasher) appendWireField(dst []byte, fp *fieldPlan, records []wireRecord) ([]byte, bool, error) {
+	fd := fp.fd
+	switch {
+	case fd.IsList():
+		return h.appendWireList(dst, fd, records)
+	case fd.IsMap():
+		return h.appendWireMap(dst, fd, records)
+	case fd.Message() != nil:
+		b := records[0].value
+		if len(records) > 1 {
+			// The values of a singular message field are merged, as parsing
+			// their concatenation does.
+			b = nil
+			for _, r := range records {
+				b = append(b, r.value...)
+			}
+		}
+		hash, err := h.appendWireMessage(dst, fd.Message(), b)
+		return hash, err == nil, err
+	}
+
+	// Every value is decoded, so that invalid ones are reported, but the last
+	// one wins.
+	var value protoreflect.Value
+	for _, r := range records {
+		var err error
+		value, _, err = decodeWireScalar(fd, r.value)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+	if !fd.HasPresence() && isZeroScalar(fd.Kind(), value) {
+		return dst, false, nil
+	}
+	hash, err := fp.appendValue(h, dst, fd, value)
+	return hash, err == nil, err
+}
+
+// appendWireList appends to dst the hash of a repeated field encoded in the
+// given records, and reports whether the list is populated.
+func (h *hasher) appendWireList(dst []byte, fd protoreflect.FieldDescriptor, records []wireRecord) ([]byte, bool, error) {
+	w := h.digest.newNode(listIdentifier)
+
+	i := 0
+	for _, r := range records {
+		if fd.Message() != nil {
+			data, err := h.appendWireMessage(w.child(), fd.Message(), r.value)
+			if err != nil {
+				w.release()
+				return nil, false, fmt.Errorf("hashing list item %d: %w", i, err)
+			}
+			w.writeChild(data)
+			i++
+			continue
+		}
+
+		packed := r.typ == protowire.BytesType && wireKindType(fd.Kind()) != protowire.BytesType
+		for b := r.value; len(b) > 0 || !packed; {
+			value, n, err := decodeWireScalar(fd, b)
+			if err != nil {
+				w.release()
+				return nil, false, fmt.Errorf("hashing list item %d: %w", i, err)
+			}
+			data, err := h.appendValue(w.child(), fd.Kind(), value)
+			if err != nil {
+				w.release()
+				return nil, false, fmt.Errorf("hashing list item %d: %w", i, err)
+			}
+			w.writeChild(data)
+			i++
+			if !packed {
+				break
+			}
+			b = b[n:]
+		}
+	}
+
+	if i == 0 {
+		// Only empty packed values were encoded.
+		w.release()
+		return dst, false, nil
+	}
+	return w.appendSum(dst), true, nil
+}
+
+// wireEntry is a map entry decoded from wire format.
+type wireEntry struct {
+	key   protoreflect.Value
+	value protoreflect.Value
+	// message is the encoded value of entries having message values.
+	message []byte
+}
+
+// appendWireMap appends to dst the hash of a map field encoded in the given
+// records.  An entry replaces the previous ones having the same key.
+func (h *hasher) appendWireMap(dst []byte, fd protoreflect.FieldDescriptor, records []wireRecord) ([]byte, bool, error) {
+	kd, vd := fd.MapKey(), fd.MapValue()
+
+	entries := make([]wireEntry, 0, len(records))
+	var index map[interface{}]int
+	for _, r := range records {
+		entry, err := decodeWireEntry(kd, vd, r.value)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(records) > 1 {
+			if index == nil {
+				index = make(map[interface{}]int, len(records))
+			}
+			key := entry.key.Interface()
+			if i, ok := index[key]; ok {
+				entries[i] = entry
+				continue
+			}
+			index[key] = len(entries)
+		}
+		entries = append(entries, entry)
+	}
+
+	w := h.digest.newNode(mapIdentifier)
+
+	// The entries are sorted by key hash once all of them are hashed.
+	sorter := &w.st.entries
+	sorter.buf = sorter.buf[:0]
+	sorter.size = w.st.hash.Size()
+
+	for _, entry := range entries {
+		var err error
+		sorter.buf, err = h.appendFieldValue(sorter.buf, kd, entry.key)
+		if err == nil {
+			if vd.Message() != nil {
+				sorter.buf, err = h.appendWireMessage(sorter.buf, vd.Message(), entry.message)
+			} else {
+				sorter.buf, err = h.appendFieldValue(sorter.buf, vd, entry.value)
+			}
+		}
+		if err != nil {
+			w.release()
+			return nil, false, fmt.Errorf("hashing map key %v: %w", entry.key.MapKey(), err)
+		}
+	}
+
+	sort.Sort(sorter)
+	w.write(sorter.buf)
+
+	return w.appendSum(dst), true, nil
+}
+
+// decodeWireEntry decodes a map entry from its wire format.  Missing keys and
+// values are the default ones, and fields encoded with an unexpected wire type
+// are ignored.
+func decodeWireEntry(kd, vd protoreflect.FieldDescriptor, b []byte) (wireEntry, error) {
+	entry := wireEntry{key: kd.Default()}
+	if vd.Message() == nil {
+		entry.value = vd.Default()
+	}
+
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return entry, fmt.Errorf("parsing map entry tag: %w", protowire.ParseError(n))
+		}
+		value, vn, err := consumeWireValue(num, typ, b[n:])
+		if err != nil {
+			return entry, fmt.Errorf("parsing map entry field %d: %w", num, err)
+		}
+		b = b[n+vn:]
+
+		var fd protoreflect.FieldDescriptor
+		switch num {
+		case 1:
+			fd = kd
+		case 2:
+			fd = vd
+		}
+		if fd == nil || typ != wireKindType(fd.Kind()) {
+			continue
+		}
+
+		if fd.Message() != nil {
+			if entry.message == nil {
+				entry.message = value
+			} else {
+				// The values are merged, as parsing their concatenation does.
+				entry.message = append(append([]byte{}, entry.message...), value...)
+			}
+			continue
+		}
+
+		decoded, _, err := decodeWireScalar(fd, value)
+		if err != nil {
+			return entry, err
+		}
+		if num == 1 {
+			entry.key = decoded
+		} else {
+			entry.value = decoded
+		}
+	}
+
+	return entry, nil
+}
+
+// consumeWireValue parses a field value of the given wire type from b, and
+// returns the encoded value (the payload of length-delimited values and the
+// content of groups) together with the number of bytes consumed.
+func consumeWireValue(num protowire.Number, typ protowire.Type, b []byte) ([]byte, int, error) {
+	switch typ {
+	case protowire.BytesType:
+		v, n := protowire.ConsumeBytes(b)
+		if n < 0 {
+			return nil, 0, protowire.ParseError(n)
+		}
+		return v, n, nil
+	case protowire.StartGroupType:
+		v, n := protowire.ConsumeGroup(num, b)
+		if n < 0 {
+			return nil, 0, protowire.ParseError(n)
+		}
+		return v, n, nil
+	}
+	n := protowire.ConsumeFieldValue(num, typ, b)
+	if n < 0 {
+		return nil, 0, protowire.ParseError(n)
+	}
+	return b[:n], n, nil
+}
+
+// wireKindType returns the wire type values of the given kind are encoded
+// with, other than packed.
+func wireKindType(kind protoreflect.Kind) protowire.Type {
+	switch kind {
+	case protoreflect.BoolKind, protoreflect.EnumKind,
+		protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Uint32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Uint64Kind:
+		return protowire.VarintType
+	case protoreflect.Sfixed32Kind, protoreflect.Fixed32Kind, protoreflect.FloatKind:
+		return protowire.Fixed32Type
+	case protoreflect.Sfixed64Kind, protoreflect.Fixed64Kind, protoreflect.DoubleKind:
+		return protowire.Fixed64Type
+	case protoreflect.GroupKind:
+		return protowire.StartGroupType
+	}
+	return protowire.BytesType
+}
+
+// wireTypeMatches reports whether values of the given field may be encoded
+// with the given wire type.  Repeated scalars may be packed or not, whatever
+// the field declares.
+func wireTypeMatches(fd protoreflect.FieldDescriptor, typ protowire.Type) bool {
+	want := wireKindType(fd.Kind())
+	if typ == want {
+		return true
+	}
+	return fd.IsList() && typ == protowire.BytesType && want != protowire.StartGroupType
+}
+
+// decodeWireScalar decodes a scalar value of the given field from b, and
+// returns it together with the number of bytes consumed.  Length-delimited
+// values are the whole of b.
+func decodeWireScalar(fd protoreflect.FieldDescriptor, b []byte) (protoreflect.Value, int, error) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		if fd.Syntax() == protoreflect.Proto3 && !utf8.Valid(b) {
+			return protoreflect.Value{}, 0, fmt.Errorf("field %s contains invalid UTF-8", fd.FullName())
+		}
+		return protoreflect.ValueOfString(string(b)), len(b), nil
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes(b), len(b), nil
+	}
+
+	switch wireKindType(fd.Kind()) {
+	case protowire.Fixed32Type:
+		v, n := protowire.ConsumeFixed32(b)
+		if n < 0 {
+			return protoreflect.Value{}, 0, protowire.ParseError(n)
+		}
+		switch fd.Kind() {
+		case protoreflect.Sfixed32Kind:
+			return protoreflect.ValueOfInt32(int32(v)), n, nil
+		case protoreflect.FloatKind:
+			return protoreflect.ValueOfFloat32(math.Float32frombits(v)), n, nil
+		}
+		return protoreflect.ValueOfUint32(v), n, nil
+	case protowire.Fixed64Type:
+		v, n := protowire.ConsumeFixed64(b)
+		if n < 0 {
+			return protoreflect.Value{}, 0, protowire.ParseError(n)
+		}
+		switch fd.Kind() {
+		case protoreflect.Sfixed64Kind:
+			return protoreflect.ValueOfInt64(int64(v)), n, nil
+		case protoreflect.DoubleKind:
+			return protoreflect.ValueOfFloat64(math.Float64frombits(v)), n, nil
+		}
+		return protoreflect.ValueOfUint64(v), n, nil
+	}
+
+	v, n := protowire.ConsumeVarint(b)
+	if n < 0 {
+		return protoreflect.Value{}, 0, protowire.ParseError(n)
+	}
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return protoreflect.ValueOfBool(protowire.DecodeBool(v)), n, nil
+	case protoreflect.EnumKind:
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(v)), n, nil
+	case protoreflect.Int32Kind:
+		return protoreflect.ValueOfInt32(int32(v)), n, nil
+	case protoreflect.Sint32Kind:
+		return protoreflect.ValueOfInt32(int32(protowire.DecodeZigZag(v & math.MaxUint32))), n, nil
+	case protoreflect.Uint32Kind:
+		return protoreflect.ValueOfUint32(uint32(v)), n, nil
+	case protoreflect.Int64Kind:
+		return protoreflect.ValueOfInt64(int64(v)), n, nil
+	case protoreflect.Sint64Kind:
+		return protoreflect.ValueOfInt64(protowire.DecodeZigZag(v)), n, nil
+	}
+	return protoreflect.ValueOfUint64(v), n, nil
+}
+
+// isZeroScalar reports whether a scalar value is the zero value of its kind,
+// which fields without presence do not distinguish from an unset value.
+func isZeroScalar(kind protoreflect.Kind, value protoreflect.Value) bool {
+	switch kind {
+	case protoreflect.BoolKind:
+		return !value.Bool()
+	case protoreflect.EnumKind:
+		return value.Enum() == 0
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return value.Int() == 0
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return value.Uint() == 0
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return math.Float64bits(value.Float()) == 0
+	case protoreflect.StringKind:
+		return len(value.String()) == 0
+	case protoreflect.BytesKind:
+		return len(value.Bytes()) == 0
+	}
+	return false
+}
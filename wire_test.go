@@ -0,0 +1,347 @@
+package protoreflecthash
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	pb2_latest "github.com/stackb/protoreflecthash/test_protos/generated/latest/proto2"
+	pb3_latest "github.com/stackb/protoreflecthash/test_protos/generated/latest/proto3"
+)
+
+// wireOptions are the option sets the wire format is hashed with.
+var wireOptions = map[string][]Option{
+	"default":                     nil,
+	"field names as keys":         {FieldNamesAsKeys()},
+	"message fullname identifier": {MessageFullnameIdentifier()},
+	"unknown fields included":     {UnknownFields(UnknownFieldsInclude)},
+	"hmac root":                   {HMAC("k", []byte("key"), KeyRoot)},
+	"hmac all nodes":              {HMAC("k", []byte("key"), KeyAllNodes)},
+}
+
+func TestHashWire(t *testing.T) {
+	st, err := structpb.NewStruct(map[string]interface{}{"a": 1.0, "b": []interface{}{"c", true, nil}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	packed, err := anypb.New(&pb3_latest.Simple{StringField: "packed"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	extended := &pb2_latest.BadWithExtensions{Text: proto.String("text")}
+	proto.SetExtension(extended, pb2_latest.E_StringExtension, "extension")
+
+	for name, msg := range map[string]proto.Message{
+		"empty": &pb3_latest.Simple{},
+		"scalars": &pb3_latest.Simple{
+			BoolField:     true,
+			BytesField:    []byte("bytes"),
+			DoubleField:   math.Pi,
+			Fixed32Field:  32,
+			FloatField:    1.5,
+			Int32Field:    -42,
+			Int64Field:    math.MinInt64,
+			Sfixed32Field: -32,
+			Sint32Field:   -21,
+			Sint64Field:   -23,
+			StringField:   "string",
+			Uint64Field:   math.MaxUint64,
+			SimpleField:   &pb3_latest.Simple{StringField: "nested"},
+		},
+		"repeated": &pb3_latest.Repetitive{
+			BoolField:    []bool{true, false},
+			DoubleField:  []float64{1, -1, math.Inf(1)},
+			Int32Field:   []int32{1, -2, 3},
+			Sint64Field:  []int64{-1, 1},
+			StringField:  []string{"a", "", "b"},
+			SimpleField:  []*pb3_latest.Simple{{}, {Int32Field: 1}},
+			BytesField:   [][]byte{{}, []byte("b")},
+			Fixed64Field: []uint64{0, math.MaxUint64},
+		},
+		"oneof": &pb3_latest.PersonV3{
+			Id:   1,
+			Name: &pb3_latest.PersonV3_StructuredName{StructuredName: &pb3_latest.PersonV3_NameV3{First: "a"}},
+		},
+		"oneof zero": &pb3_latest.Singleton{Singleton: &pb3_latest.Singleton_TheInt32{}},
+		"maps": &pb3_latest.StringMaps{
+			StringToString: map[string]string{"a": "b", "": ""},
+			StringToSimple: map[string]*pb3_latest.Simple{"a": {}, "b": {BoolField: true}},
+			StringToDouble: map[string]float64{"nan": math.NaN()},
+		},
+		"well-known types": &pb3_latest.KnownTypes{
+			AnyField:         packed,
+			StructField:      st,
+			TimestampField:   timestamppb.New(time.Unix(1, 2)),
+			BoolValueField:   wrapperspb.Bool(false),
+			StringValueField: wrapperspb.String("s"),
+		},
+		"proto2 defaults": &pb2_latest.Simple{BoolField: proto.Bool(false), Int32Field: proto.Int32(0), StringField: proto.String("")},
+		"groups": &pb2_latest.Groups{
+			Optionalgroup: &pb2_latest.Groups_OptionalGroup{StringField: proto.String("foo")},
+			Repeatedgroup: []*pb2_latest.Groups_RepeatedGroup{{StringField: proto.String("bar")}, {}},
+		},
+		"extensions": extended,
+	} {
+		b, err := proto.Marshal(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for optionsName, options := range wireOptions {
+			t.Run(name+"/"+optionsName, func(t *testing.T) {
+				h := NewHasher(options...)
+				want, err := h.HashProto(msg.ProtoReflect())
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := h.(WireProtoHasher).HashWire(msg.ProtoReflect().Descriptor(), b)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(want, got) {
+					t.Errorf("want hash %x, got %x", want, got)
+				}
+			})
+		}
+	}
+}
+
+// TestHashWireEncodings checks wire encodings that proto.Marshal does not
+// produce, which hash as the message proto.Unmarshal parses from them.
+func TestHashWireEncodings(t *testing.T) {
+	simple := (&pb3_latest.Simple{}).ProtoReflect().Descriptor()
+	repetitive := (&pb3_latest.Repetitive{}).ProtoReflect().Descriptor()
+	singleton := (&pb3_latest.Singleton{}).ProtoReflect().Descriptor()
+	maps := (&pb3_latest.StringMaps{}).ProtoReflect().Descriptor()
+
+	varint := func(b []byte, num protowire.Number, v uint64) []byte {
+		return protowire.AppendVarint(protowire.AppendTag(b, num, protowire.VarintType), v)
+	}
+	bytesField := func(b []byte, num protowire.Number, v []byte) []byte {
+		return protowire.AppendBytes(protowire.AppendTag(b, num, protowire.BytesType), v)
+	}
+	packed := func(b []byte, num protowire.Number, vs ...uint64) []byte {
+		var p []byte
+		for _, v := range vs {
+			p = protowire.AppendVarint(p, v)
+		}
+		return bytesField(b, num, p)
+	}
+	entry := func(key, value []byte) []byte {
+		var b []byte
+		if key != nil {
+			b = bytesField(b, 1, key)
+		}
+		if value != nil {
+			b = bytesField(b, 2, value)
+		}
+		return b
+	}
+
+	// Unknown fields precede the known fields having the same numbers, making
+	// more than 12 entries, beyond which sorting them is not stable.
+	var colliding []byte
+	for _, num := range []protowire.Number{29, 1, 27, 13, 23, 15, 21} {
+		colliding = protowire.AppendFixed32(protowire.AppendTag(colliding, num, protowire.Fixed32Type), uint32(num))
+		colliding = varint(colliding, num, uint64(num))
+	}
+
+	for name, tc := range map[string]struct {
+		md protoreflect.MessageDescriptor
+		b  []byte
+	}{
+		"last scalar wins": {
+			md: simple,
+			b:  varint(varint(nil, 13, 1), 13, 2),
+		},
+		"last scalar is zero": {
+			md: simple,
+			b:  varint(varint(nil, 13, 5), 13, 0),
+		},
+		"negative zero": {
+			md: simple,
+			b:  protowire.AppendFixed64(protowire.AppendTag(nil, 5, protowire.Fixed64Type), math.Float64bits(math.Copysign(0, -1))),
+		},
+		"out of order fields": {
+			md: simple,
+			b:  bytesField(varint(bytesField(nil, 25, []byte("s")), 1, 1), 31, varint(nil, 13, 1)),
+		},
+		"merged messages": {
+			md: simple,
+			b:  bytesField(bytesField(bytesField(nil, 31, varint(nil, 13, 1)), 1, nil), 31, bytesField(nil, 25, []byte("s"))),
+		},
+		"packed and unpacked": {
+			md: repetitive,
+			b:  varint(packed(varint(nil, 13, 1), 13, 2, 3), 13, 4),
+		},
+		"packed strings are not": {
+			md: repetitive,
+			b:  bytesField(packed(nil, 25, 1, 2), 25, nil),
+		},
+		"empty packed": {
+			md: repetitive,
+			b:  packed(nil, 13),
+		},
+		"oneof member replaced": {
+			md: singleton,
+			b:  bytesField(bytesField(bytesField(nil, 31, varint(nil, 13, 1)), 25, []byte("s")), 31, bytesField(nil, 25, []byte("t"))),
+		},
+		"oneof member merged": {
+			md: singleton,
+			b:  bytesField(bytesField(nil, 31, varint(nil, 13, 1)), 31, bytesField(nil, 25, []byte("t"))),
+		},
+		"duplicate map keys": {
+			md: maps,
+			b:  bytesField(bytesField(bytesField(nil, 13, entry([]byte("a"), []byte("1"))), 13, entry([]byte("b"), []byte("2"))), 13, entry([]byte("a"), []byte("3"))),
+		},
+		"map entry without key or value": {
+			md: maps,
+			b:  bytesField(bytesField(nil, 13, entry(nil, []byte("1"))), 17, entry([]byte("a"), nil)),
+		},
+		"map entry values merged": {
+			md: maps,
+			b:  bytesField(nil, 17, bytesField(bytesField(bytesField(nil, 1, []byte("a")), 2, varint(nil, 13, 1)), 2, varint(nil, 1, 1))),
+		},
+		"unexpected wire type": {
+			md: simple,
+			b:  bytesField(varint(nil, 13, 1), 13, []byte("x")),
+		},
+		"unknown fields": {
+			md: simple,
+			b:  varint(bytesField(varint(nil, 1000, 1), 30, []byte("x")), 2, 7),
+		},
+		"unknown fields numbered as known ones": {
+			md: simple,
+			b:  colliding,
+		},
+	} {
+		msg := dynamicpb.NewMessage(tc.md)
+		if err := proto.Unmarshal(tc.b, msg); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for optionsName, options := range wireOptions {
+			t.Run(name+"/"+optionsName, func(t *testing.T) {
+				h := NewHasher(options...)
+				want, err := h.HashProto(msg)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := h.(WireProtoHasher).HashWire(tc.md, tc.b)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(want, got) {
+					t.Errorf("want hash %x, got %x", want, got)
+				}
+			})
+		}
+	}
+}
+
+func TestHashWireErrors(t *testing.T) {
+	simple := (&pb3_latest.Simple{}).ProtoReflect().Descriptor()
+	singleton := (&pb3_latest.Singleton{}).ProtoReflect().Descriptor()
+	theInt32 := protowire.AppendVarint(protowire.AppendTag(nil, 13, protowire.VarintType), 1)
+
+	for name, tc := range map[string]struct {
+		options []Option
+		md      protoreflect.MessageDescriptor
+		b       []byte
+		want    string
+	}{
+		"truncated": {
+			md:   simple,
+			b:    append(protowire.AppendVarint(protowire.AppendTag(nil, 25, protowire.BytesType), 5), "ab"...),
+			want: "parsing field 25",
+		},
+		"truncated nested": {
+			md:   simple,
+			b:    protowire.AppendBytes(protowire.AppendTag(nil, 31, protowire.BytesType), []byte{0xff}),
+			want: "hashing field value 31",
+		},
+		"invalid UTF-8": {
+			md:   simple,
+			b:    protowire.AppendBytes(protowire.AppendTag(nil, 25, protowire.BytesType), []byte{0xff}),
+			want: "invalid UTF-8",
+		},
+		"truncated replaced oneof member": {
+			md:   singleton,
+			b:    append(protowire.AppendBytes(protowire.AppendTag(nil, 31, protowire.BytesType), []byte{0xff}), theInt32...),
+			want: "parsing field 31",
+		},
+		"invalid UTF-8 in replaced oneof member": {
+			md:   singleton,
+			b:    append(protowire.AppendBytes(protowire.AppendTag(nil, 25, protowire.BytesType), []byte{0xff}), theInt32...),
+			want: "invalid UTF-8",
+		},
+		"missing required field": {
+			md:   (&pb2_latest.BadWithRequirements{}).ProtoReflect().Descriptor(),
+			want: "required field schema.proto2.BadWithRequirements.text not set",
+		},
+		"unknown fields rejected": {
+			options: []Option{UnknownFields(UnknownFieldsReject)},
+			md:      simple,
+			b:       protowire.AppendVarint(protowire.AppendTag(nil, 1000, protowire.VarintType), 1),
+			want:    ErrUnknownFields.Error(),
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewHasher(tc.options...).(WireProtoHasher).HashWire(tc.md, tc.b)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("want error containing %q, got %v", tc.want, err)
+			}
+			if tc.want == ErrUnknownFields.Error() && !errors.Is(err, ErrUnknownFields) {
+				t.Errorf("want error %v, got %v", ErrUnknownFields, err)
+			}
+			// Without options, the data is rejected as proto.Unmarshal rejects it.
+			if tc.options == nil {
+				if err := proto.Unmarshal(tc.b, dynamicpb.NewMessage(tc.md)); err == nil {
+					t.Error("want proto.Unmarshal error, got nil")
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkHashWire(b *testing.B) {
+	for name, msg := range benchmarkMessages(b) {
+		data, err := proto.Marshal(msg)
+		if err != nil {
+			b.Fatal(err)
+		}
+		md := msg.ProtoReflect().Descriptor()
+		h := NewHasher()
+
+		b.Run(name+"/unmarshal", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				msg := dynamicpb.NewMessage(md)
+				if err := proto.Unmarshal(data, msg); err != nil {
+					b.Fatal(err)
+				}
+				if _, err := h.HashProto(msg); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(name+"/wire", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := h.(WireProtoHasher).HashWire(md, data); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}